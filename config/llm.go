@@ -0,0 +1,44 @@
+package config
+
+import (
+	"aiagent/repository"
+	"aiagent/services/llm"
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	llmClient     llm.Client
+	llmClientOnce sync.Once
+)
+
+// GetLLMClient function returns the model client configured through the environment.
+// The client is shared so every route uses the same circuit breakers and cache.
+func GetLLMClient() llm.Client {
+	llmClientOnce.Do(func() {
+		settings := llm.SettingsFromEnv()
+		llmClient = llm.NewCacheClient(llm.NewClient(settings), getCacheRepo("LLMCache"), settings.Cache)
+	})
+	return llmClient
+}
+
+// getCacheRepo returns the collection of a cache and makes sure its documents
+// expire through a TTL index on expires_at
+func getCacheRepo(collectionName string) repository.Repository {
+	collection := GetCollection(DB, collectionName)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Warn("Error creating TTL index for ", collectionName, ": ", err)
+	}
+	return GetRepoCollection(collectionName)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"aiagent/services/scraper"
+	"aiagent/services/website"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	pageScraper     scraper.Scraper
+	pageScraperOnce sync.Once
+
+	politeGate     *scraper.Gate
+	politeGateOnce sync.Once
+)
+
+// getPoliteGate returns the gate shared by the scraper and the website
+// resolver, see scraper.PolitenessFromEnv
+func getPoliteGate() *scraper.Gate {
+	politeGateOnce.Do(func() {
+		politeGate = scraper.NewGate(scraper.PolitenessFromEnv())
+	})
+	return politeGate
+}
+
+// GetScraper function returns the scraper configured through the environment.
+// Scraped pages are cached for SCRAPE_CACHE_TTL_HOURS, 72 by default, and
+// pages that are not cached are fetched politely, see scraper.PolitenessFromEnv.
+// The content of every page is cleaned and capped to SCRAPE_MAX_CONTENT_BYTES,
+// 20000 by default.
+func GetScraper() scraper.Scraper {
+	pageScraperOnce.Do(func() {
+		ttlHours, err := strconv.Atoi(os.Getenv("SCRAPE_CACHE_TTL_HOURS"))
+		if err != nil || ttlHours <= 0 {
+			ttlHours = 72
+		}
+		maxContentBytes, err := strconv.Atoi(os.Getenv("SCRAPE_MAX_CONTENT_BYTES"))
+		if err != nil || maxContentBytes <= 0 {
+			maxContentBytes = 20000
+		}
+		fetcher := scraper.NewCleaner(scraper.NewPolite(scraper.New(), getPoliteGate()), maxContentBytes)
+		pageScraper = scraper.NewCache(fetcher, getCacheRepo("ScrapeCache"), time.Duration(ttlHours)*time.Hour)
+	})
+	return pageScraper
+}
+
+// GetCrawler function returns the company website crawler, bounded by
+// CRAWL_MAX_PAGES and CRAWL_MAX_BYTES and fetching through GetScraper
+func GetCrawler() *scraper.Crawler {
+	return scraper.NewCrawler(GetScraper(), scraper.CrawlBudgetFromEnv())
+}
+
+var (
+	websiteResolver     *website.Resolver
+	websiteResolverOnce sync.Once
+)
+
+// GetWebsiteResolver function returns the shared company website resolver,
+// it remembers the probed domains across uploads and probes them politely
+func GetWebsiteResolver() *website.Resolver {
+	websiteResolverOnce.Do(func() {
+		websiteResolver = website.NewResolver(10*time.Second, getPoliteGate())
+	})
+	return websiteResolver
+}
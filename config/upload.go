@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetUploadWorkers function returns the number of upload rows processed
+// concurrently, read from UPLOAD_WORKERS and 4 by default
+func GetUploadWorkers() int {
+	workers, err := strconv.Atoi(os.Getenv("UPLOAD_WORKERS"))
+	if err != nil || workers <= 0 {
+		return 4
+	}
+	return workers
+}
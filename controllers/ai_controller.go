@@ -2,108 +2,207 @@ package controllers
 
 import (
 	"aiagent/models"
+	"aiagent/repository"
 	"aiagent/responses"
-	"aiagent/services"
-	"bufio"
-	"bytes"
+	"aiagent/services/linkedin"
+	"aiagent/services/llm"
+	"aiagent/services/scraper"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 // GenerateAI				godoc
 // @Tags					AIAgent Apis
 // @Summary					Generate with AI
-// @Description				Generate with AI
+// @Description				Generate with AI. Without stream the data is the answer as a chat completion JSON string, read the text from choices[0].message.content. With stream set the answer is sent as Server-Sent Events: "delta" events with the content, then a "done" event with the completion or an "error" event.
 // @Param					GenerateAI body models.GenerateAIBody true "Generate Body Response"
-// @Produce					application/json
-// @Success					200 {object} responses.ApplicationResponse{}
+// @Produce					application/json,text/event-stream
+// @Success					200 {object} responses.ApplicationResponse{data=string}
 // @Router					/initializ/v1/ai/generatewithAI [POST]
-func GeneratewithAIHandler() gin.HandlerFunc {
+func GeneratewithAIHandler(llmClient llm.Client, pageScraper scraper.Scraper, generatedOutputRepo repository.Repository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var body models.GenerateAIBody
 		ctx.BindJSON(&body)
 
+		// The placeholder is left untouched unless research was requested
+		research := "**research**"
 		if body.TODOResearch {
-			scrapedData, err := services.ScrapeData(body.Linkedin_url)
+			profile, err := linkedin.Parse(body.Linkedin_url)
 			if err != nil {
-				ReturnResponse(ctx, http.StatusBadRequest, "Error occurred while scraping the URL: "+err.Error(), nil)
+				ReturnResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+				return
+			}
+			if !profile.Scrapable() {
+				ReturnResponse(ctx, http.StatusBadRequest, "Sales Navigator URLs cannot be scraped, use the public profile URL", nil)
+				return
+			}
+			page, err := pageScraper.Scrape(scraper.WithForceRefresh(ctx.Request.Context(), body.ForceRefresh), profile.ScrapeURL())
+			if err != nil {
+				ReturnResponse(ctx, scrapeStatus(err), "Error occurred while scraping the URL: "+err.Error(), nil)
 				return
 			}
 
 			// Replace the placeholder with the scraped data
-			body.Task = strings.ReplaceAll(body.Task, "**research**", scrapedData)
+			research = page.Content
+		}
+		modelConfig := newModelConfig(body.ModelSettings, body.SystemPrompt, "")
+		modelConfig.BypassCache = body.BypassCache
+		truncated := llm.RenderPrompt(llmClient, &modelConfig, body.Task, []llm.Placeholder{
+			{Key: "**research**", Value: research, Trimmable: body.TODOResearch},
+		})
+		if len(truncated) > 0 {
+			ctx.Header("X-Prompt-Truncated", strings.Join(truncated, ","))
 		}
-		modelUri := os.Getenv("MODELURI")
-		var modelConfig models.ModelConfig
-		var message models.Message
-		message.Role = "system"
-		message.Content = body.SystemPrompt
-		modelConfig.Messages = append(modelConfig.Messages, message)
-		message.Role = "user"
-		message.Content = body.Task
-		modelConfig.Messages = append(modelConfig.Messages, message)
-		modelConfig.Model = "meta-llama/Meta-Llama-3.1-8B-Instruct"
-		modelConfig.Stream = body.Stream
-		modelConfig.Temperature = 0.7
-		modelConfig.MaxTokens = 5000
 
-		modelBody, _ := json.Marshal(modelConfig)
+		// Check for streaming
+		if body.Stream {
+			streamCompletion(ctx, llmClient, generatedOutputRepo, body, modelConfig)
+			return
+		}
 
-		req, err := http.NewRequest("POST", modelUri, bytes.NewBuffer(modelBody))
+		// Non-streaming: wait for the full response and return it
+		completion, err := llmClient.Complete(ctx.Request.Context(), modelConfig)
 		if err != nil {
-			ReturnResponse(ctx, http.StatusBadRequest, "Error occured while generating the response.", nil)
+			ReturnResponse(ctx, http.StatusBadRequest, "Error occured while generating the response: "+err.Error(), nil)
 			return
 		}
-		req.Header.Set("Authorization", "Bearer "+os.Getenv("TOKEN"))
-		resp, err := http.DefaultClient.Do(req)
+		if body.Persist {
+			saveGeneratedOutput(generatedOutputRepo, body, completion)
+		}
+		answer, err := chatCompletionBody(completion)
 		if err != nil {
-			ReturnResponse(ctx, http.StatusBadRequest, "Error occured while generating the response.", nil)
+			ReturnResponse(ctx, http.StatusBadRequest, "Error occurred while parsing the AI response", nil)
 			return
 		}
-		defer resp.Body.Close()
+		ReturnResponse(ctx, http.StatusOK, "Successfully generate the response", answer)
+	}
+}
 
-		// Check for streaming
-		if modelConfig.Stream {
-			ctx.Header("Content-Type", "text/event-stream")
-			// Stream the response from Model API to the client
-			scanner := bufio.NewScanner(resp.Body)
-			for scanner.Scan() {
-				chunk := scanner.Text()
-				ctx.Writer.WriteString(chunk + "\n")
-				ctx.Writer.Flush()
-			}
-		} else {
-			// Non-streaming: Accumulate the response and return once fully received
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				ReturnResponse(ctx, http.StatusBadRequest, "Error occured while generating the response.", nil)
-				return
-			}
-			// Return the full response in JSON format
-			var aiResponse struct {
-				Choices []struct {
-					Message struct {
-						Content string `json:"content"`
-					} `json:"message"`
-				} `json:"choices"`
-			}
+// chatCompletion is the OpenAI chat completion body generatewithAI has always
+// answered with, whichever provider generated the completion
+type chatCompletion struct {
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   models.TokenUsage      `json:"usage"`
+}
 
-			// Unmarshal the response body into the structure
-			if err := json.Unmarshal(bodyBytes, &aiResponse); err != nil {
-				ReturnResponse(ctx, http.StatusBadRequest, "Error occurred while parsing the AI response", nil)
-				return
-			}
+type chatCompletionChoice struct {
+	Index        int            `json:"index"`
+	Message      models.Message `json:"message"`
+	FinishReason string         `json:"finish_reason"`
+}
+
+// chatCompletionBody encodes the completion as the chat completion JSON string
+// clients of the non-streaming generatewithAI parse
+func chatCompletionBody(completion *llm.Completion) (string, error) {
+	body, err := json.Marshal(chatCompletion{
+		Model: completion.Model,
+		Choices: []chatCompletionChoice{{
+			Message:      models.Message{Role: "assistant", Content: completion.Content},
+			FinishReason: "stop",
+		}},
+		Usage: completion.Usage,
+	})
+	return string(body), err
+}
+
+// streamCompletion proxies the model stream to the client as Server-Sent Events.
+// Every piece of content is sent as a "delta" event, the stream ends with a
+// "done" event carrying the assembled completion or an "error" event. The
+// upstream request is cancelled as soon as the client disconnects.
+func streamCompletion(ctx *gin.Context, llmClient llm.Client, generatedOutputRepo repository.Repository, body models.GenerateAIBody, modelConfig models.ModelConfig) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
 
-			// Return the full response in JSON format
-			ctx.Header("Content-Type", "application/json")
-			ReturnResponse(ctx, http.StatusOK, "Successfully generate the response", string(bodyBytes))
+	completion, err := llmClient.Stream(ctx.Request.Context(), modelConfig, func(delta string) error {
+		return writeEvent(ctx, "delta", gin.H{"content": delta})
+	})
+	if ctx.Request.Context().Err() != nil {
+		log.Info("Client disconnected, stream cancelled")
+		return
+	}
+	if err != nil {
+		log.Error("Error occured while streaming the response: ", err)
+		event := gin.H{"message": err.Error()}
+		var apiErr *llm.APIError
+		if errors.As(err, &apiErr) {
+			event["status"] = apiErr.StatusCode
 		}
+		writeEvent(ctx, "error", event)
+		return
+	}
+	if body.Persist {
+		saveGeneratedOutput(generatedOutputRepo, body, completion)
+	}
+	writeEvent(ctx, "done", completion)
+}
+
+// writeEvent sends a single Server-Sent Event with a JSON payload
+func writeEvent(ctx *gin.Context, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(ctx.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	ctx.Writer.Flush()
+	return nil
+}
+
+// saveGeneratedOutput stores the assembled answer of a generate request
+func saveGeneratedOutput(generatedOutputRepo repository.Repository, body models.GenerateAIBody, completion *llm.Completion) {
+	_, err := generatedOutputRepo.InsertOne(models.GeneratedOutput{
+		SystemPrompt: body.SystemPrompt,
+		Task:         body.Task,
+		Content:      completion.Content,
+		Provider:     completion.Provider,
+		Model:        completion.Model,
+		Usage:        completion.Usage,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Error("Error occurred while saving the generated output: ", err)
+	}
+}
+
+// defaultTemperature is used when the settings do not set a temperature
+const defaultTemperature float32 = 0.7
+
+// newModelConfig builds the chat request sent to the model for a system and user prompt.
+// Settings left empty fall back to the defaults below, the provider and model
+// fall back to the configured provider defaults.
+func newModelConfig(settings models.ModelSettings, systemPrompt string, userPrompt string) models.ModelConfig {
+	modelConfig := models.ModelConfig{
+		Provider:    settings.Provider,
+		Model:       settings.Model,
+		Temperature: settings.Temperature,
+		TopP:        settings.TopP,
+		MaxTokens:   settings.MaxTokens,
+		Stop:        settings.Stop,
+		Messages: []models.Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	if modelConfig.Temperature == nil {
+		temperature := defaultTemperature
+		modelConfig.Temperature = &temperature
+	}
+	if modelConfig.MaxTokens == 0 {
+		modelConfig.MaxTokens = 5000
 	}
+	return modelConfig
 }
 
 func ReturnResponse(ctx *gin.Context, statusCode int, message string, data interface{}) {
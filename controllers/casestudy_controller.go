@@ -4,7 +4,7 @@ import (
 	"aiagent/models"
 	"aiagent/repository"
 	"aiagent/responses"
-	"aiagent/services"
+	"aiagent/services/scraper"
 	"context"
 	"net/http"
 
@@ -21,19 +21,20 @@ import (
 // @Param                    request body models.Casestudy true  "Case Study"
 // @Success					200 {object} responses.ApplicationResponse{}
 // @Router					/initializ/v1/ai/casestudy [POST]
-func SaveCaseStudy(caseStudyRepo repository.Repository) gin.HandlerFunc {
+func SaveCaseStudy(caseStudyRepo repository.Repository, pageScraper scraper.Scraper) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var body models.CaseStudy
+		var body models.Casestudy
 		if err := c.BindJSON(&body); err != nil {
 			ReturnResponse(c, http.StatusBadRequest, "Invalid input", nil)
 			return
 		}
 		// Call the scrapeData function to get the scraped content
-		researchedData, err := services.ScrapeData(body.URL)
+		page, err := pageScraper.Scrape(scraper.WithForceRefresh(c.Request.Context(), body.ForceRefresh), body.URL)
 		if err != nil {
-			ReturnResponse(c, http.StatusBadRequest, "Error occurred while scraping the data", nil)
+			ReturnResponse(c, scrapeStatus(err), "Error occurred while scraping the data: "+string(scraper.KindOf(err)), nil)
 			return
 		}
+		researchedData := page.Content
 		caseStudy := models.CaseStudy{
 			URL:            body.URL,
 			ResearchedData: researchedData,
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/repository"
+	"aiagent/services/spreadsheet"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Prospect fields an upload column can be mapped to
+const (
+	fieldName        = "name"
+	fieldExperience  = "experience"
+	fieldLocation    = "location"
+	fieldMobileNo    = "mobile no"
+	fieldEmail       = "email"
+	fieldDesignation = "designation"
+	fieldCompany     = "company"
+	fieldLinkedinURL = "linkedin url"
+	fieldCompanyURL  = "company url"
+)
+
+// prospectFields pick their column in this order, so the more specific
+// "company url" is matched before "company" could claim an ambiguous header
+var prospectFields = []string{
+	fieldName, fieldEmail, fieldLinkedinURL, fieldCompanyURL, fieldCompany,
+	fieldDesignation, fieldMobileNo, fieldLocation, fieldExperience,
+}
+
+// defaultColumnAliases are the headers recognized without a mapping profile,
+// the first one of every field is the header of the original template
+var defaultColumnAliases = map[string][]string{
+	fieldName:        {"name", "full name", "contact name", "prospect name", "lead name"},
+	fieldExperience:  {"experience", "years of experience", "total experience", "seniority"},
+	fieldLocation:    {"location", "city", "country", "region", "address"},
+	fieldMobileNo:    {"mobile no", "mobile", "mobile number", "mobile phone", "phone", "phone number", "contact number", "direct phone"},
+	fieldEmail:       {"email", "email address", "e mail", "work email", "business email", "email id"},
+	fieldDesignation: {"designation", "title", "job title", "position", "role"},
+	fieldCompany:     {"company", "company name", "organization", "organisation", "account name", "account", "employer"},
+	fieldLinkedinURL: {"linkedin url", "linkedin", "linkedin profile", "linkedin profile url", "person linkedin url", "profile url"},
+	fieldCompanyURL:  {"company url", "website", "company website", "website url", "company domain", "domain"},
+}
+
+// SaveColumnMapping			godoc
+// @Tags					UserData Apis
+// @Summary					Save Column Mapping
+// @Description				Create or replace the column mapping profile with the same name
+// @Param					mapping body models.ColumnMapping true "Column mapping"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{}
+// @Router					/initializ/v1/ai/columnmappings [POST]
+func SaveColumnMapping(mappingRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var mapping models.ColumnMapping
+		if err := c.BindJSON(&mapping); err != nil {
+			ReturnResponse(c, http.StatusBadRequest, "Invalid input", nil)
+			return
+		}
+		if mapping.Name == "" || len(mapping.Aliases) == 0 {
+			ReturnResponse(c, http.StatusBadRequest, "The name and at least one field alias are required", nil)
+			return
+		}
+		for field := range mapping.Aliases {
+			if _, known := defaultColumnAliases[field]; !known {
+				ReturnResponse(c, http.StatusBadRequest, fmt.Sprintf("Unknown field %q, the fields are %v", field, prospectFields), nil)
+				return
+			}
+		}
+
+		now := time.Now()
+		update := bson.M{
+			"$set":         bson.M{"aliases": mapping.Aliases, "updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		}
+		if err := mappingRepo.UpdateOne(bson.M{"name": mapping.Name}, update, options.Update().SetUpsert(true)); err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error saving the column mapping", nil)
+			return
+		}
+		ReturnResponse(c, http.StatusOK, "Column mapping saved successfully", nil)
+	}
+}
+
+// GetColumnMappings			godoc
+// @Tags					UserData Apis
+// @Summary					Get Column Mappings
+// @Description				Get the saved column mapping profiles and the built-in header aliases
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{}
+// @Router					/initializ/v1/ai/columnmappings [GET]
+func GetColumnMappings(mappingRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cursor, err := mappingRepo.FindWithOption(bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the data from db : "+err.Error(), nil)
+			return
+		}
+		defer cursor.Close(context.TODO())
+
+		mappings := []models.ColumnMapping{}
+		if err := cursor.All(context.TODO(), &mappings); err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the data from db : "+err.Error(), nil)
+			return
+		}
+		ReturnResponse(c, http.StatusOK, "Successfully fetched the column mappings", gin.H{
+			"profiles": mappings,
+			"defaults": defaultColumnAliases,
+		})
+	}
+}
+
+// DeleteColumnMapping		godoc
+// @Tags					UserData Apis
+// @Summary					Delete Column Mapping
+// @Description				Delete a column mapping profile by ID
+// @Param					id path string true "Column mapping ID"
+// @Success					200 {object} responses.ApplicationResponse{}
+// @Router					/initializ/v1/ai/columnmappings/{id} [DELETE]
+func DeleteColumnMapping(mappingRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			ReturnResponse(c, http.StatusBadRequest, "Invalid column mapping ID format.", nil)
+			return
+		}
+		mappingRepo.DeleteMany(bson.M{"_id": objectID})
+		ReturnResponse(c, http.StatusOK, "Column mapping deleted successfully", nil)
+	}
+}
+
+// columnAliases returns the built-in aliases preceded by the aliases of the
+// mapping profile, given by name or ID
+func columnAliases(mappingRepo repository.Repository, profile string) (map[string][]string, error) {
+	if profile == "" {
+		return mergeAliases(nil), nil
+	}
+
+	filter := bson.M{"name": profile}
+	if objectID, err := primitive.ObjectIDFromHex(profile); err == nil {
+		filter = bson.M{"$or": []bson.M{{"name": profile}, {"_id": objectID}}}
+	}
+	var mapping models.ColumnMapping
+	if err := mappingRepo.FindOne(filter).Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("column mapping %q not found", profile)
+	}
+	return mergeAliases(mapping.Aliases), nil
+}
+
+// mergeAliases puts the aliases of a mapping profile in front of the built-in ones
+func mergeAliases(profileAliases map[string][]string) map[string][]string {
+	aliases := make(map[string][]string, len(defaultColumnAliases))
+	for field, headers := range defaultColumnAliases {
+		aliases[field] = headers
+	}
+	for field, headers := range profileAliases {
+		aliases[field] = append(append([]string{}, headers...), aliases[field]...)
+	}
+	return aliases
+}
+
+// mapProspect reads the prospect of a row, missing cells are left empty
+func mapProspect(columns spreadsheet.Columns, row []string) models.UserDetails {
+	return models.UserDetails{
+		Name:               columns.Value(row, fieldName),
+		Experience:         columns.Value(row, fieldExperience),
+		Location:           columns.Value(row, fieldLocation),
+		MobileNo:           columns.Value(row, fieldMobileNo),
+		Email:              columns.Value(row, fieldEmail),
+		Designation:        columns.Value(row, fieldDesignation),
+		CompanyDetails:     columns.Value(row, fieldCompany),
+		LinkedInProfileUrl: columns.Value(row, fieldLinkedinURL),
+		CompanyWebsite:     columns.Value(row, fieldCompanyURL),
+	}
+}
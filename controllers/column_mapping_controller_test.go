@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/services/spreadsheet"
+	"reflect"
+	"testing"
+)
+
+func TestMapProspect(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		row     []string
+		profile map[string][]string
+		want    models.UserDetails
+	}{
+		{
+			name:    "original template",
+			headers: []string{"Name", "Email", "Mobile No", "Company", "Company URL", "Designation", "LinkedIn URL"},
+			row:     []string{"Ann", "ann@acme.com", "+1 555 0100", "Acme", "acme.com", "CTO", "linkedin.com/in/ann"},
+			want: models.UserDetails{Name: "Ann", Email: "ann@acme.com", MobileNo: "+1 555 0100", CompanyDetails: "Acme",
+				CompanyWebsite: "acme.com", Designation: "CTO", LinkedInProfileUrl: "linkedin.com/in/ann"},
+		},
+		{
+			name:    "crm export",
+			headers: []string{"Full Name", "Work Email", "Job Title", "Account Name", "Website", "Person Linkedin Url", "City"},
+			row:     []string{"Ann", "ann@acme.com", "CTO", "Acme", "acme.com", "linkedin.com/in/ann", "Berlin"},
+			want: models.UserDetails{Name: "Ann", Email: "ann@acme.com", Designation: "CTO", CompanyDetails: "Acme",
+				CompanyWebsite: "acme.com", LinkedInProfileUrl: "linkedin.com/in/ann", Location: "Berlin"},
+		},
+		{
+			name:    "unmapped columns and short rows",
+			headers: []string{"Name", "Notes", "Email"},
+			row:     []string{"Ann", "met at a fair"},
+			want:    models.UserDetails{Name: "Ann"},
+		},
+		{
+			name:    "profile overrides a default",
+			headers: []string{"Name", "Contact", "Email"},
+			row:     []string{"Acme", "Ann", "ann@acme.com"},
+			profile: map[string][]string{fieldName: {"contact"}, fieldCompany: {"name"}},
+			want:    models.UserDetails{Name: "Ann", CompanyDetails: "Acme", Email: "ann@acme.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns := spreadsheet.MatchColumns(test.headers, prospectFields, mergeAliases(test.profile))
+			if got := mapProspect(columns, test.row); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("mapProspect() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeAliasesKeepsTheDefaults(t *testing.T) {
+	mergeAliases(map[string][]string{fieldName: {"contact"}})
+	if defaultColumnAliases[fieldName][0] != "name" {
+		t.Errorf("default name aliases changed to %v", defaultColumnAliases[fieldName])
+	}
+}
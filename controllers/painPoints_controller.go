@@ -4,13 +4,10 @@ import (
 	"aiagent/models"
 	"aiagent/repository"
 	"aiagent/responses"
-	"bytes"
+	"aiagent/services/llm"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -64,7 +61,7 @@ func GetPainPoints(painPointRepo repository.Repository) gin.HandlerFunc {
 // @Param                    request body models.PainPointRole true  "Pain Points and Value Proposition"
 // @Success					200 {object} responses.ApplicationResponse{}
 // @Router					/initializ/v1/ai/painpoints [POST]
-func SaveAiResponseToDB(painPointRepo repository.Repository) gin.HandlerFunc {
+func SaveAiResponseToDB(painPointRepo repository.Repository, llmClient llm.Client) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var apiResponseData struct {
 			Role string `json:"role"`
@@ -77,14 +74,14 @@ func SaveAiResponseToDB(painPointRepo repository.Repository) gin.HandlerFunc {
 		}
 
 		// Call service to generate pain points
-		painPoints, valueProposition, err := GeneratePainPointsUsingAI(apiResponseData.Role)
+		items, generated, err := GeneratePainPointsUsingAI(ctx.Request.Context(), llmClient, apiResponseData.Role)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error generating pain points: %v", err)})
 			return
 		}
 
 		// Save the content to the database
-		err = SavePainPoints(painPointRepo, painPoints, valueProposition, apiResponseData.Role)
+		_, err = SavePainPoints(painPointRepo, apiResponseData.Role, items, generated)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error saving pain point to the database: %v", err)})
 			return
@@ -96,89 +93,69 @@ func SaveAiResponseToDB(painPointRepo repository.Repository) gin.HandlerFunc {
 		})
 	}
 }
-func GeneratePainPointsUsingAI(role string) (string, string, error) {
-	modelUrl := os.Getenv("MODELURI")
-	var modelConfig models.ModelConfig
-	var message models.Message
-	message.Role = "system"
-	message.Content = "You are an expert assistant representing Initializ.ai, a platform specializing in developing, securing, and operating cloud-native and AI applications.\r\n\r\nTask:\r\nWhen provided with a job title (e.g., Software Development Engineer, Project Manager), your task is to:\r\n\r\n1. Identify key pain points for the given role.\r\n\r\n2. Explain how Initializ.ai addresses these pain points using its features.\r\n\r\n3. Integrate Initializ.ai's values—simplification, security, innovation, and collaboration—into the response.\r\n\r\n4. Provide a clear, professional response in 50 words or less.\r\n"
-	modelConfig.Messages = append(modelConfig.Messages, message)
-	message.Role = "user"
-	message.Content = role
-	modelConfig.Messages = append(modelConfig.Messages, message)
-	modelConfig.Model = "meta-llama/Meta-Llama-3.1-8B-Instruct"
-	modelConfig.Stream = false
-	modelConfig.Temperature = 0.7
-	modelConfig.MaxTokens = 5000
-
-	modelBody, _ := json.Marshal(modelConfig)
-
-	req, err := http.NewRequest("POST", modelUrl, bytes.NewBuffer(modelBody))
-	if err != nil {
-		return "", "", fmt.Errorf("error creating request: %v", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("TOKEN"))
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("error generating response from AI model: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var ModelResponse struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
 
-	resbody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("error reading response body: %v", err)
-	}
+// painPointSystemPrompt instructs the model how to describe the pain points of a role
+const painPointSystemPrompt = "You are an expert assistant representing Initializ.ai, a platform specializing in developing, securing, and operating cloud-native and AI applications.\r\n\r\nTask:\r\nWhen provided with a job title (e.g., Software Development Engineer, Project Manager), your task is to:\r\n\r\n1. Identify key pain points for the given role.\r\n\r\n2. Explain how Initializ.ai addresses each of these pain points using its features.\r\n\r\n3. Integrate Initializ.ai's values—simplification, security, innovation, and collaboration—into the response.\r\n\r\n4. Keep every pain point and value proposition clear, professional and under 50 words.\r\n"
 
-	err = json.Unmarshal(resbody, &ModelResponse)
-	if err != nil {
-		return "", "", fmt.Errorf("error unmarshalling AI model response: %v", err)
-	}
+// painPointSchema is the JSON shape the model has to answer with
+const painPointSchema = `{"pain_points": [{"pain_point": "string, a key pain point of the role", "value_proposition": "string, how Initializ.ai addresses this pain point"}]}`
 
-	generatedContent := ModelResponse.Choices[0].Message.Content
-	painPoints, valueProposition := splitContent(generatedContent)
+// painPointAttempts is how often the model is asked before giving up on invalid answers
+const painPointAttempts = 3
 
-	return painPoints, valueProposition, nil
-}
+// painPointsName is the prompt name the pain point generations are reported under
+const painPointsName = "Pain Points"
+
+// GeneratePainPointsUsingAI asks the model for the pain points of a role, each with
+// the matching value proposition, and records the model call
+func GeneratePainPointsUsingAI(ctx context.Context, llmClient llm.Client, role string) ([]models.PainPointItem, *models.AiGenerated, error) {
+	var answer struct {
+		PainPoints []models.PainPointItem `json:"pain_points"`
+	}
+	validate := func() error {
+		if len(answer.PainPoints) == 0 {
+			return fmt.Errorf("pain_points must contain at least one item")
+		}
+		for i, item := range answer.PainPoints {
+			if strings.TrimSpace(item.PainPoint) == "" || strings.TrimSpace(item.ValueProposition) == "" {
+				return fmt.Errorf("pain_points[%d] needs both a pain_point and a value_proposition", i)
+			}
+		}
+		return nil
+	}
 
-func splitContent(content string) (string, string) {
-	content = strings.ReplaceAll(content, "*", "")
-	content = strings.ReplaceAll(content, "How Initializ Helps:", "How Initializ.ai Helps:")
-	parts := strings.Split(content, "How Initializ.ai Helps:")
-	var painPoints string
-	var valueProposition string
-	if len(parts) > 1 {
-		painPoints = parts[0]
-		valueProposition = parts[1]
-	} else {
-		painPoints = content
-		valueProposition = "" // Empty value if not found
+	modelConfig := newModelConfig(models.ModelSettings{}, painPointSystemPrompt, role)
+	completion, err := llm.CompleteJSON(ctx, llmClient, modelConfig, painPointSchema, &answer, validate, painPointAttempts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating response from AI model: %v", err)
 	}
-	return painPoints, valueProposition
+	generated := newAiGenerated(painPointsName, completion)
+	return answer.PainPoints, &generated, nil
 }
 
-// SavePainPoints saves the generated content as a pain point in the database
-func SavePainPoints(painPointRepo repository.Repository, painPoints, valueProposition, role string) error {
+// SavePainPoints saves the generated pain points of a role in the database. The
+// combined texts are kept next to the items for the prompts that use them.
+func SavePainPoints(painPointRepo repository.Repository, role string, items []models.PainPointItem, generated *models.AiGenerated) (models.PainPointModel, error) {
+	var painPoints, valuePropositions []string
+	for i, item := range items {
+		painPoints = append(painPoints, fmt.Sprintf("%d. %s", i+1, strings.TrimSpace(item.PainPoint)))
+		valuePropositions = append(valuePropositions, fmt.Sprintf("%d. %s", i+1, strings.TrimSpace(item.ValueProposition)))
+	}
 	painPoint := models.PainPointModel{
 		Role:             role,
-		PainPoint:        painPoints,
-		ValueProposition: valueProposition,
+		PainPoint:        strings.Join(painPoints, "\n"),
+		ValueProposition: strings.Join(valuePropositions, "\n"),
+		Items:            items,
 		CreatedAt:        time.Now(),
+		Generated:        generated,
 	}
 
 	// Insert the pain point into the database
 	_, err := painPointRepo.InsertOne(painPoint)
 	if err != nil {
-		return fmt.Errorf("error saving pain point to the database: %v", err)
+		return painPoint, fmt.Errorf("error saving pain point to the database: %v", err)
 	}
-	return nil
+	return painPoint, nil
 }
 
 // DeleteCaseStudy				godoc
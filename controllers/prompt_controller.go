@@ -153,6 +153,12 @@ func UpdatePromptById(aIPromptsRepo repository.Repository) gin.HandlerFunc {
 				"updated_by":  body.UpdatedBy,
 				"prompt":      body.Prompt,
 				"prompt_rule": body.PromptRule,
+				"provider":    body.Provider,
+				"model":       body.Model,
+				"temperature": body.Temperature,
+				"top_p":       body.TopP,
+				"max_tokens":  body.MaxTokens,
+				"stop":        body.Stop,
 			},
 		}
 		err = aIPromptsRepo.UpdateOne(filter, update, nil)
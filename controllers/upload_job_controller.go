@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/repository"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetUploadJob				godoc
+// @Tags					UserData Apis
+// @Summary					Get Upload Job
+// @Description				Get the status of an upload job: rows total, done, failed and skipped, the current phase and the estimated completion time
+// @Param					jobId path string true "jobId"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{data=models.UploadJob}
+// @Router					/initializ/v1/ai/upload/jobs/{jobId} [GET]
+func GetUploadJob(jobRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var job models.UploadJob
+		err := jobRepo.FindOne(bson.M{"_id": c.Param("jobId")}).Decode(&job)
+		if err == mongo.ErrNoDocuments {
+			ReturnResponse(c, http.StatusNotFound, "Upload job not found", nil)
+			return
+		}
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload job : "+err.Error(), nil)
+			return
+		}
+		job.ETA = estimateCompletion(job)
+		ReturnResponse(c, http.StatusOK, "Successfully fetched the upload job", job)
+	}
+}
+
+// GetUploadJobRows			godoc
+// @Tags					UserData Apis
+// @Summary					Get Upload Job Rows
+// @Description				Get the result of every row of an upload job, optionally filtered by status (pending, running, done, failed or skipped)
+// @Param					jobId path string true "jobId"
+// @Param					status query string false "Row status"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{data=[]models.UploadJobRow}
+// @Router					/initializ/v1/ai/upload/jobs/{jobId}/rows [GET]
+func GetUploadJobRows(jobRowRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{"job_id": c.Param("jobId")}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+		cursor, err := jobRowRepo.FindWithOption(filter, options.Find().SetSort(bson.M{"row": 1}))
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload rows : "+err.Error(), nil)
+			return
+		}
+		defer cursor.Close(context.TODO())
+
+		rows := []models.UploadJobRow{}
+		if err := cursor.All(context.TODO(), &rows); err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload rows : "+err.Error(), nil)
+			return
+		}
+		ReturnResponse(c, http.StatusOK, "Successfully fetched the upload rows", rows)
+	}
+}
+
+// DownloadUploadJobErrors		godoc
+// @Tags					UserData Apis
+// @Summary					Download Upload Job Errors
+// @Description				Download an xlsx sheet listing the issues found in the rows of an upload job, the rows that failed and the rows with outputs that could not be generated
+// @Param					jobId path string true "jobId"
+// @Produce					application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Success					200 {file} file
+// @Router					/initializ/v1/ai/upload/jobs/{jobId}/errors [GET]
+func DownloadUploadJobErrors(jobRepo repository.Repository, jobRowRepo repository.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var job models.UploadJob
+		err := jobRepo.FindOne(bson.M{"_id": c.Param("jobId")}).Decode(&job)
+		if err == mongo.ErrNoDocuments {
+			ReturnResponse(c, http.StatusNotFound, "Upload job not found", nil)
+			return
+		}
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload job : "+err.Error(), nil)
+			return
+		}
+
+		cursor, err := jobRowRepo.Find(bson.M{"job_id": job.ID, "status": bson.M{"$in": []string{models.RowFailed, models.RowDone}}})
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload rows : "+err.Error(), nil)
+			return
+		}
+		defer cursor.Close(context.TODO())
+		var processedRows []models.UploadJobRow
+		if err := cursor.All(context.TODO(), &processedRows); err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error occured while fetching the upload rows : "+err.Error(), nil)
+			return
+		}
+
+		issues := job.Issues
+		for _, row := range processedRows {
+			switch {
+			case row.Status == models.RowFailed:
+				issues = append(issues, models.UploadIssue{Row: row.Row, Message: row.Error, Severity: models.IssueError})
+			case row.Error != "":
+				// Saved with some of the outputs missing
+				issues = append(issues, models.UploadIssue{Row: row.Row, Message: row.Error, Severity: models.IssueWarning})
+			}
+		}
+		errorsFile, err := issuesSheet(issues)
+		if err != nil {
+			ReturnResponse(c, http.StatusInternalServerError, "Error creating the errors sheet : "+err.Error(), nil)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=upload-%s-errors.xlsx", job.ID))
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", errorsFile)
+	}
+}
+
+// FailInterruptedUploadJobs marks the jobs left queued or running by a previous
+// run of the server as failed, their rows are not resumed
+func FailInterruptedUploadJobs(jobRepo repository.Repository, jobRowRepo repository.Repository) {
+	cursor, err := jobRepo.Find(bson.M{"status": bson.M{"$in": []string{models.JobQueued, models.JobRunning}}})
+	if err != nil {
+		log.Error("Error fetching interrupted upload jobs: ", err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []models.UploadJob
+	if err := cursor.All(context.TODO(), &jobs); err != nil {
+		log.Error("Error fetching interrupted upload jobs: ", err)
+		return
+	}
+	now := time.Now()
+	for _, job := range jobs {
+		update := bson.M{"status": models.JobFailed, "error": "interrupted by a server restart", "phase": "", "finished_at": now, "updated_at": now}
+		if err := jobRepo.UpdateOne(bson.M{"_id": job.ID}, bson.M{"$set": update}, nil); err != nil {
+			log.Error("Error updating upload job ", job.ID, ": ", err)
+		}
+		err := jobRowRepo.UpdateMany(bson.M{"job_id": job.ID, "status": bson.M{"$in": []string{models.RowPending, models.RowRunning}}},
+			bson.M{"$set": bson.M{"status": models.RowFailed, "error": "interrupted by a server restart", "phase": ""}})
+		if err != nil {
+			log.Error("Error updating the rows of upload job ", job.ID, ": ", err)
+		}
+	}
+}
+
+// estimateCompletion extrapolates the pace of the rows processed so far to
+// the remaining rows
+func estimateCompletion(job models.UploadJob) *time.Time {
+	if job.Status != models.JobRunning || job.StartedAt == nil {
+		return nil
+	}
+	processed := job.RowsDone + job.RowsFailed
+	remaining := job.RowsTotal - job.RowsSkipped - processed
+	if processed == 0 || remaining <= 0 {
+		return nil
+	}
+	perRow := time.Since(*job.StartedAt) / time.Duration(processed)
+	eta := time.Now().Add(perRow * time.Duration(remaining))
+	return &eta
+}
@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/repository"
+	"aiagent/services/linkedin"
+	"aiagent/services/llm"
+	"aiagent/services/scraper"
+	"aiagent/services/website"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UploadPipeline researches the prospects of an upload in the background and
+// tracks the progress in an upload job. Rows of all uploads share a pool of
+// Workers, scraping and model calls are further limited by the scraper and
+// the model client.
+type UploadPipeline struct {
+	// Workers is the number of rows processed concurrently, 1 when not set
+	Workers int
+
+	UserDataRepo    repository.Repository
+	PainPointRepo   repository.Repository
+	JobRepo         repository.Repository
+	JobRowRepo      repository.Repository
+	LLMClient       llm.Client
+	Scraper         scraper.Scraper
+	Crawler         *scraper.Crawler
+	WebsiteResolver *website.Resolver
+
+	workersOnce sync.Once
+	workers     chan struct{}
+}
+
+// uploadRow is a prospect read from a row of an upload
+type uploadRow struct {
+	// Number is the spreadsheet row number, the header being row 1
+	Number int
+	User   models.UserDetails
+	// LinkedinScrapeURL is empty when the LinkedIn URL is missing or not a public profile
+	LinkedinScrapeURL string
+	// SkipReason is set for rows that are not processed
+	SkipReason string
+}
+
+// uploadOptions are the per upload settings of the pipeline
+type uploadOptions struct {
+	BypassCache  bool
+	ForceRefresh bool
+	// DryRun only validates the rows, no job is started
+	DryRun bool
+}
+
+// prepareRows validates the rows, canonicalizes the LinkedIn URLs, skips
+// invalid and duplicate prospects and returns the issues found
+func prepareRows(rows []uploadRow) []models.UploadIssue {
+	var issues []models.UploadIssue
+	// Canonical LinkedIn profile URL to row number, to skip duplicate prospects
+	profileRows := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		rowIssues := validateRow(row)
+		var invalid []string
+		for _, issue := range rowIssues {
+			if issue.Severity == models.IssueError {
+				invalid = append(invalid, issue.Message)
+			}
+		}
+		if len(invalid) > 0 {
+			row.SkipReason = "invalid row: " + strings.Join(invalid, ", ")
+		}
+		issues = append(issues, rowIssues...)
+		if row.User.LinkedInProfileUrl == "" {
+			continue
+		}
+		profile, err := linkedin.Parse(row.User.LinkedInProfileUrl)
+		switch {
+		case err != nil:
+			issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldLinkedinURL, Value: row.User.LinkedInProfileUrl, Message: err.Error(), Severity: models.IssueWarning})
+		case profile.Kind != linkedin.KindProfile:
+			issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldLinkedinURL, Value: row.User.LinkedInProfileUrl, Message: "expected a public profile URL, got a " + strings.ReplaceAll(profile.Kind, "_", " ") + " URL", Severity: models.IssueWarning})
+		case row.SkipReason != "":
+			// Invalid rows do not claim the profile of a later valid row
+		default:
+			if number, duplicate := profileRows[profile.Canonical]; duplicate {
+				row.SkipReason = fmt.Sprintf("duplicate of row %d", number)
+				issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldLinkedinURL, Value: row.User.LinkedInProfileUrl, Message: row.SkipReason + ", skipped", Severity: models.IssueError})
+				continue
+			}
+			profileRows[profile.Canonical] = row.Number
+			row.User.LinkedInProfileUrl = profile.Canonical
+			row.LinkedinScrapeURL = profile.ScrapeURL()
+		}
+	}
+	return issues
+}
+
+// Start creates the upload job with one result per row and processes the rows
+// in the background. The returned job is queued.
+func (p *UploadPipeline) Start(rows []uploadRow, prompts map[string]models.Prompts, options uploadOptions) (*models.UploadJob, error) {
+	now := time.Now()
+	job := &models.UploadJob{
+		ID:        primitive.NewObjectID().Hex(),
+		Status:    models.JobQueued,
+		RowsTotal: len(rows),
+		Issues:    prepareRows(rows),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	jobRows := make([]interface{}, 0, len(rows))
+	for i := range rows {
+		rows[i].User.UploadID = job.ID
+		jobRow := models.UploadJobRow{
+			ID:     rowID(job.ID, rows[i].Number),
+			JobID:  job.ID,
+			Row:    rows[i].Number,
+			Name:   rows[i].User.Name,
+			Email:  rows[i].User.Email,
+			Status: models.RowPending,
+		}
+		if rows[i].SkipReason != "" {
+			jobRow.Status = models.RowSkipped
+			jobRow.Error = rows[i].SkipReason
+			job.RowsSkipped++
+		}
+		jobRows = append(jobRows, jobRow)
+	}
+
+	if _, err := p.JobRepo.InsertOne(job); err != nil {
+		return nil, err
+	}
+	if len(jobRows) > 0 {
+		if _, err := p.JobRowRepo.InsertMany(jobRows, nil); err != nil {
+			p.finish(job.ID, fmt.Errorf("error saving the rows: %w", err))
+			return nil, err
+		}
+	}
+
+	go p.run(job.ID, rows, prompts, options)
+	return job, nil
+}
+
+// acquireWorker waits for a free worker of the pool and returns the function releasing it
+func (p *UploadPipeline) acquireWorker() func() {
+	p.workersOnce.Do(func() {
+		p.workers = make(chan struct{}, max(p.Workers, 1))
+	})
+	p.workers <- struct{}{}
+	return func() { <-p.workers }
+}
+
+// run hands the rows to the worker pool and waits for all of them, the
+// result of every row is recorded independently of the order they finish in
+func (p *UploadPipeline) run(jobID string, rows []uploadRow, prompts map[string]models.Prompts, options uploadOptions) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Error("Upload job ", jobID, " crashed: ", recovered)
+			p.finish(jobID, fmt.Errorf("upload job crashed: %v", recovered))
+		}
+	}()
+
+	startedAt := time.Now()
+	p.updateJob(jobID, bson.M{"$set": bson.M{"status": models.JobRunning, "started_at": startedAt, "updated_at": startedAt}})
+
+	// Pages scraped by earlier uploads are reused unless a refresh was requested
+	ctx := scraper.WithForceRefresh(context.Background(), options.ForceRefresh)
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		if row.SkipReason != "" {
+			continue
+		}
+		release := p.acquireWorker()
+		wg.Add(1)
+		go func(row uploadRow) {
+			defer wg.Done()
+			defer release()
+			p.runRow(ctx, jobID, row, prompts, options)
+		}(row)
+	}
+	wg.Wait()
+
+	log.Info("Upload job ", jobID, " completed")
+	p.finish(jobID, nil)
+}
+
+// runRow processes a single row and records its result, a failing row never
+// stops the job
+func (p *UploadPipeline) runRow(ctx context.Context, jobID string, row uploadRow, prompts map[string]models.Prompts, options uploadOptions) {
+	id := rowID(jobID, row.Number)
+	startedAt := time.Now()
+	p.updateRow(id, bson.M{"status": models.RowRunning, "started_at": startedAt})
+
+	user, err := p.processRow(ctx, jobID, row, prompts, options)
+	finishedAt := time.Now()
+	result := bson.M{"finished_at": finishedAt, "phase": ""}
+	counter := "rows_done"
+	if err != nil {
+		log.Error("Error processing row ", row.Number, " of upload job ", jobID, ": ", err)
+		result["status"] = models.RowFailed
+		result["error"] = err.Error()
+		counter = "rows_failed"
+	} else {
+		result["status"] = models.RowDone
+		result["user_id"] = user.ID
+		result["scrape_errors"] = user.ScrapeErrors
+		if user.Error != "" {
+			result["error"] = user.Error
+		}
+	}
+	p.updateRow(id, result)
+	p.updateJob(jobID, bson.M{"$inc": bson.M{counter: 1}, "$set": bson.M{"updated_at": finishedAt}})
+}
+
+// processedUser is what is recorded about a saved prospect
+type processedUser struct {
+	ID           string
+	ScrapeErrors []models.ScrapeError
+	// Error lists the outputs that failed when the others were generated
+	Error string
+}
+
+// processRow scrapes the prospect and the company, generates the AI outputs
+// and saves the prospect
+func (p *UploadPipeline) processRow(ctx context.Context, jobID string, row uploadRow, prompts map[string]models.Prompts, options uploadOptions) (saved *processedUser, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("row processing crashed: %v", recovered)
+		}
+	}()
+
+	user := row.User
+	id := rowID(jobID, row.Number)
+	p.setPhase(jobID, id, models.PhaseScraping)
+
+	var wg sync.WaitGroup
+	var linkedinErr, companyErr *models.ScrapeError
+	wg.Add(2)
+	// The recover of the row does not cover these goroutines
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				linkedinErr = newScrapeError("linkedin", row.LinkedinScrapeURL, fmt.Errorf("scraping crashed: %v", recovered))
+			}
+		}()
+		if row.LinkedinScrapeURL == "" {
+			return
+		}
+		linkedinPage, err := p.Scraper.Scrape(ctx, row.LinkedinScrapeURL)
+		if err != nil {
+			log.Warn("Error fetching LinkedIn data for", user.Name, ":", err)
+			linkedinErr = newScrapeError("linkedin", row.LinkedinScrapeURL, err)
+		} else {
+			user.LinkedInProfileData = linkedinPage.Content
+		}
+	}()
+
+	// Find the company website from the company url, email domain or
+	// company name and crawl its key pages
+	var companySite *scraper.Site
+	var websiteErr error
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				websiteErr = fmt.Errorf("company website lookup crashed: %v", recovered)
+			}
+		}()
+		resolution, err := p.WebsiteResolver.Resolve(ctx, user.CompanyWebsite, user.Email, user.CompanyDetails)
+		if err != nil {
+			websiteErr = err
+			return
+		}
+		user.CompanyWebsite = resolution.URL
+		user.CompanyWebsiteSource = resolution.Source
+		site, err := p.Crawler.Crawl(ctx, resolution.URL)
+		if err != nil {
+			log.Warn("Error fetching company data for", user.CompanyDetails, ":", err)
+			companyErr = newScrapeError("company", resolution.URL, err)
+		} else {
+			companySite = site
+		}
+	}()
+	wg.Wait()
+	if websiteErr != nil {
+		issue := models.UploadIssue{Row: row.Number, Field: fieldCompanyURL, Value: user.CompanyDetails, Message: websiteErr.Error(), Severity: models.IssueWarning}
+		p.updateJob(jobID, bson.M{"$push": bson.M{"issues": issue}})
+	}
+	for _, scrapeErr := range []*models.ScrapeError{linkedinErr, companyErr} {
+		if scrapeErr != nil {
+			user.ScrapeErrors = append(user.ScrapeErrors, *scrapeErr)
+		}
+	}
+
+	if companySite != nil {
+		p.setPhase(jobID, id, models.PhaseSummarizing)
+		user.CompanyPages = companySite.Pages
+		user.CompanyResearchedData, user.CompanySummary = summarizeCompany(ctx, p.LLMClient, companySite, options.BypassCache)
+	}
+
+	// Generate AI Output for the user
+	p.setPhase(jobID, id, models.PhaseGenerating)
+	aiOutput, generationErr := generateAiOutput(ctx, p.LLMClient, user, prompts, p.PainPointRepo, options.BypassCache)
+	if generationErr != nil && !generatedAny(aiOutput) {
+		return nil, fmt.Errorf("no output could be generated: %w", generationErr)
+	}
+	user.AiOutput = aiOutput
+
+	p.setPhase(jobID, id, models.PhaseSaving)
+	insertedID, err := p.UserDataRepo.InsertOne(user)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while inserting user data: %w", err)
+	}
+	saved = &processedUser{ScrapeErrors: user.ScrapeErrors}
+	if generationErr != nil {
+		saved.Error = "some outputs could not be generated: " + generationErr.Error()
+	}
+	if objectID, ok := insertedID.(primitive.ObjectID); ok {
+		saved.ID = objectID.Hex()
+	}
+	return saved, nil
+}
+
+// finish marks the job completed, or failed when err is set
+func (p *UploadPipeline) finish(jobID string, err error) {
+	now := time.Now()
+	update := bson.M{"status": models.JobCompleted, "phase": "", "finished_at": now, "updated_at": now}
+	if err != nil {
+		update["status"] = models.JobFailed
+		update["error"] = err.Error()
+	}
+	p.updateJob(jobID, bson.M{"$set": update})
+}
+
+func (p *UploadPipeline) setPhase(jobID string, id string, phase string) {
+	p.updateRow(id, bson.M{"phase": phase})
+	p.updateJob(jobID, bson.M{"$set": bson.M{"phase": phase, "updated_at": time.Now()}})
+}
+
+func (p *UploadPipeline) updateJob(jobID string, update bson.M) {
+	if err := p.JobRepo.UpdateOne(bson.M{"_id": jobID}, update, nil); err != nil {
+		log.Error("Error updating upload job ", jobID, ": ", err)
+	}
+}
+
+func (p *UploadPipeline) updateRow(id string, fields bson.M) {
+	if err := p.JobRowRepo.UpdateOne(bson.M{"_id": id}, bson.M{"$set": fields}, nil); err != nil {
+		log.Error("Error updating upload row ", id, ": ", err)
+	}
+}
+
+func rowID(jobID string, number int) string {
+	return fmt.Sprintf("%s-%d", jobID, number)
+}
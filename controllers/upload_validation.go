@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/services/spreadsheet"
+	"encoding/base64"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// requiredFields must have a column in the upload and a value in every row
+var requiredFields = []string{fieldName, fieldEmail}
+
+var (
+	phoneExtension = regexp.MustCompile(`(?i)\s*(ext\.?|x)\s*\d{1,6}$`)
+	phoneNumber    = regexp.MustCompile(`^\+?[\d\s().\-/]+$`)
+)
+
+// validateRow checks the values of a row. Rows with IssueError issues are
+// not imported, invalid phone numbers are cleared.
+func validateRow(row *uploadRow) []models.UploadIssue {
+	var issues []models.UploadIssue
+	user := &row.User
+	if user.Name == "" {
+		issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldName, Message: "name is required", Severity: models.IssueError})
+	}
+	if user.Email == "" {
+		issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldEmail, Message: "email is required", Severity: models.IssueError})
+	} else if !validEmail(user.Email) {
+		issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldEmail, Value: user.Email, Message: "not a valid email address", Severity: models.IssueError})
+	}
+	if user.MobileNo != "" && !validPhone(user.MobileNo) {
+		issues = append(issues, models.UploadIssue{Row: row.Number, Field: fieldMobileNo, Value: user.MobileNo, Message: "not a valid phone number, expected 7 to 15 digits", Severity: models.IssueWarning})
+		user.MobileNo = ""
+	}
+	return issues
+}
+
+// validEmail accepts a bare address with a dotted domain, "Ann <ann@acme.com>" is rejected
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return false
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// validPhone accepts an optional country code and extension and the usual
+// separators around 7 to 15 digits
+func validPhone(phone string) bool {
+	phone = phoneExtension.ReplaceAllString(phone, "")
+	if !phoneNumber.MatchString(phone) {
+		return false
+	}
+	digits := 0
+	for _, char := range phone {
+		if char >= '0' && char <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// missingRequiredFields returns the required fields without a column
+func missingRequiredFields(columns spreadsheet.Columns) []string {
+	var missing []string
+	for _, field := range requiredFields {
+		if _, mapped := columns[field]; !mapped {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// newUploadReport validates the rows like an upload would, nothing is saved
+func newUploadReport(rows []uploadRow) (*models.UploadReport, error) {
+	report := &models.UploadReport{RowsTotal: len(rows), Issues: prepareRows(rows)}
+	if report.Issues == nil {
+		report.Issues = []models.UploadIssue{}
+	}
+	for _, row := range rows {
+		if row.SkipReason != "" {
+			report.RowsInvalid++
+		} else {
+			report.RowsValid++
+		}
+	}
+	if len(report.Issues) > 0 {
+		errorsFile, err := issuesSheet(report.Issues)
+		if err != nil {
+			return nil, err
+		}
+		report.ErrorsFile = base64.StdEncoding.EncodeToString(errorsFile)
+	}
+	return report, nil
+}
+
+// issuesSheet lists the issues by row in an xlsx workbook
+func issuesSheet(issues []models.UploadIssue) ([]byte, error) {
+	sorted := append([]models.UploadIssue{}, issues...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Row < sorted[j].Row })
+	rows := [][]string{{"Row", "Field", "Value", "Severity", "Message"}}
+	for _, issue := range sorted {
+		rows = append(rows, []string{strconv.Itoa(issue.Row), issue.Field, issue.Value, issue.Severity, issue.Message})
+	}
+	return spreadsheet.Write("Errors", rows)
+}
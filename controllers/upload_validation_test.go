@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/services/spreadsheet"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestValidEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"ann@acme.com", true},
+		{"ann.lee+sales@mail.acme.co.uk", true},
+		{"ann@acme", false},
+		{"ann@.acme.com", false},
+		{"ann@acme.com.", false},
+		{"ann.acme.com", false},
+		{"Ann <ann@acme.com>", false},
+		{"ann@@acme.com", false},
+		{"ann @acme.com", false},
+	}
+	for _, test := range tests {
+		if got := validEmail(test.email); got != test.want {
+			t.Errorf("validEmail(%q) = %v, want %v", test.email, got, test.want)
+		}
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"+1 (555) 010-0100", true},
+		{"555.0100.12", true},
+		{"+49 30 1234567 ext. 12", true},
+		{"030 1234567 x12", true},
+		{"123456", false},
+		{"+1234567890123456", false},
+		{"call me", false},
+		{"555-0100 or 555-0101", false},
+	}
+	for _, test := range tests {
+		if got := validPhone(test.phone); got != test.want {
+			t.Errorf("validPhone(%q) = %v, want %v", test.phone, got, test.want)
+		}
+	}
+}
+
+func TestMissingRequiredFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns spreadsheet.Columns
+		want    []string
+	}{
+		{"all present", spreadsheet.Columns{fieldName: 0, fieldEmail: 1}, nil},
+		{"email missing", spreadsheet.Columns{fieldName: 0, fieldCompany: 1}, []string{fieldEmail}},
+		{"none", spreadsheet.Columns{}, []string{fieldName, fieldEmail}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := missingRequiredFields(test.columns); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("missingRequiredFields() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPrepareRows(t *testing.T) {
+	prospect := func(number int, name, email, linkedinURL string) uploadRow {
+		return uploadRow{Number: number, User: models.UserDetails{Name: name, Email: email, LinkedInProfileUrl: linkedinURL}}
+	}
+	tests := []struct {
+		name       string
+		rows       []uploadRow
+		wantSkip   []string
+		wantIssues []models.UploadIssue
+	}{
+		{
+			name:       "valid rows",
+			rows:       []uploadRow{prospect(2, "Ann", "ann@acme.com", "linkedin.com/in/ann"), prospect(3, "Bob", "bob@acme.com", "")},
+			wantSkip:   []string{"", ""},
+			wantIssues: nil,
+		},
+		{
+			name:     "missing name and invalid email",
+			rows:     []uploadRow{prospect(2, "", "ann@acme", "")},
+			wantSkip: []string{"invalid row: name is required, not a valid email address"},
+			wantIssues: []models.UploadIssue{
+				{Row: 2, Field: fieldName, Message: "name is required", Severity: models.IssueError},
+				{Row: 2, Field: fieldEmail, Value: "ann@acme", Message: "not a valid email address", Severity: models.IssueError},
+			},
+		},
+		{
+			name:     "duplicate LinkedIn profile",
+			rows:     []uploadRow{prospect(2, "Ann", "ann@acme.com", "https://www.linkedin.com/in/ann/"), prospect(3, "Ann L", "ann.l@acme.com", "linkedin.com/in/ANN")},
+			wantSkip: []string{"", "duplicate of row 2"},
+			wantIssues: []models.UploadIssue{
+				{Row: 3, Field: fieldLinkedinURL, Value: "linkedin.com/in/ANN", Message: "duplicate of row 2, skipped", Severity: models.IssueError},
+			},
+		},
+		{
+			name:     "invalid rows do not claim a profile",
+			rows:     []uploadRow{prospect(2, "Ann", "", "linkedin.com/in/ann"), prospect(3, "Ann", "ann@acme.com", "linkedin.com/in/ann")},
+			wantSkip: []string{"invalid row: email is required", ""},
+			wantIssues: []models.UploadIssue{
+				{Row: 2, Field: fieldEmail, Message: "email is required", Severity: models.IssueError},
+			},
+		},
+		{
+			name:     "company page is a warning",
+			rows:     []uploadRow{prospect(2, "Ann", "ann@acme.com", "linkedin.com/company/acme")},
+			wantSkip: []string{""},
+			wantIssues: []models.UploadIssue{
+				{Row: 2, Field: fieldLinkedinURL, Value: "linkedin.com/company/acme", Message: "expected a public profile URL, got a company URL", Severity: models.IssueWarning},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues := prepareRows(test.rows)
+			if !reflect.DeepEqual(issues, test.wantIssues) {
+				t.Errorf("issues = %+v, want %+v", issues, test.wantIssues)
+			}
+			for i, row := range test.rows {
+				if row.SkipReason != test.wantSkip[i] {
+					t.Errorf("row %d skip reason = %q, want %q", row.Number, row.SkipReason, test.wantSkip[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPrepareRowsClearsInvalidPhones(t *testing.T) {
+	rows := []uploadRow{{Number: 2, User: models.UserDetails{Name: "Ann", Email: "ann@acme.com", MobileNo: "12"}}}
+	issues := prepareRows(rows)
+	if len(issues) != 1 || issues[0].Severity != models.IssueWarning || issues[0].Field != fieldMobileNo {
+		t.Fatalf("issues = %+v, want a mobile no warning", issues)
+	}
+	if rows[0].SkipReason != "" || rows[0].User.MobileNo != "" {
+		t.Errorf("row = %+v, want it kept without the phone number", rows[0])
+	}
+}
+
+func TestNewUploadReport(t *testing.T) {
+	rows := []uploadRow{
+		{Number: 2, User: models.UserDetails{Name: "Ann", Email: "ann@acme.com", LinkedInProfileUrl: "linkedin.com/in/ann"}},
+		{Number: 3, User: models.UserDetails{Name: "Bob", Email: "bob"}},
+		{Number: 4, User: models.UserDetails{Name: "Ann", Email: "ann@acme.com", LinkedInProfileUrl: "linkedin.com/in/ann"}},
+		{Number: 5, User: models.UserDetails{Name: "Cid", Email: "cid@acme.com", MobileNo: "12"}},
+	}
+	report, err := newUploadReport(rows)
+	if err != nil {
+		t.Fatalf("newUploadReport() error = %v", err)
+	}
+	if report.RowsTotal != 4 || report.RowsValid != 2 || report.RowsInvalid != 2 || len(report.Issues) != 3 {
+		t.Errorf("report = %d total, %d valid, %d invalid, %d issues, want 4, 2, 2 and 3",
+			report.RowsTotal, report.RowsValid, report.RowsInvalid, len(report.Issues))
+	}
+
+	errorsFile, err := base64.StdEncoding.DecodeString(report.ErrorsFile)
+	if err != nil {
+		t.Fatalf("ErrorsFile is not base64: %v", err)
+	}
+	sheet, err := spreadsheet.Read(errorsFile, "errors.xlsx", "Errors")
+	if err != nil {
+		t.Fatalf("reading the errors file: %v", err)
+	}
+	if len(sheet) != 4 || sheet[1][0] != "3" || sheet[3][0] != "5" {
+		t.Errorf("errors sheet = %q, want a header and the issues by row", sheet)
+	}
+}
+
+func TestNewUploadReportWithoutIssues(t *testing.T) {
+	report, err := newUploadReport([]uploadRow{{Number: 2, User: models.UserDetails{Name: "Ann", Email: "ann@acme.com"}}})
+	if err != nil {
+		t.Fatalf("newUploadReport() error = %v", err)
+	}
+	if report.RowsValid != 1 || report.Issues == nil || len(report.Issues) != 0 || report.ErrorsFile != "" {
+		t.Errorf("report = %+v, want one valid row, an empty issue list and no errors file", report)
+	}
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"aiagent/models"
+	"aiagent/repository"
+	"aiagent/responses"
+	"aiagent/services/llm"
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetUsage				godoc
+// @Tags					Usage Apis
+// @Summary					Get Token Usage and Cost
+// @Description				Get token usage and cost of the generated outputs, company summaries and pain points, aggregated per upload, prompt and model. Pain points are shared by every prospect with the same role and are not part of an upload.
+// @Param					upload_id query string false "Only include the prospects of this upload, pain points are left out"
+// @Param					prompt query string false "Only include the outputs of this prompt"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{data=models.UsageReport}
+// @Router					/initializ/v1/ai/usage [GET]
+func GetUsage(userDataRepo repository.Repository, painPointRepo repository.Repository, prices llm.Prices) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := bson.M{}
+		uploadID := c.Query("upload_id")
+		if uploadID != "" {
+			filter["upload_id"] = uploadID
+		}
+		findOptions := options.Find().SetProjection(bson.M{"upload_id": 1, "ai_output": 1, "company_summary": 1})
+		cursor, err := userDataRepo.FindWithOption(filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Error occured while fetching the data from db : " + err.Error(),
+			})
+			return
+		}
+		defer cursor.Close(context.TODO())
+
+		var userData []models.UserDetails
+		if err := cursor.All(context.TODO(), &userData); err != nil {
+			c.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Error occured while fetching the data from db : " + err.Error(),
+			})
+			return
+		}
+
+		var painPoints []models.PainPointModel
+		if uploadID == "" {
+			painPointCursor, err := painPointRepo.FindWithOption(bson.M{"generated": bson.M{"$exists": true}}, options.Find().SetProjection(bson.M{"generated": 1}))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Error occured while fetching the data from db : " + err.Error(),
+				})
+				return
+			}
+			defer painPointCursor.Close(context.TODO())
+			if err := painPointCursor.All(context.TODO(), &painPoints); err != nil {
+				c.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+					Status:  http.StatusBadRequest,
+					Message: "Error occured while fetching the data from db : " + err.Error(),
+				})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, responses.ApplicationResponse{
+			Status:  http.StatusOK,
+			Message: "Successfully fetched the usage",
+			Data:    buildUsageReport(userData, painPoints, c.Query("prompt"), prices),
+		})
+	}
+}
+
+// buildUsageReport sums the usage of every generated output, company summary
+// and pain point generation, cached outputs are counted but not billed. Pain
+// points belong to a role rather than an upload and are left out of ByUpload.
+func buildUsageReport(userData []models.UserDetails, painPoints []models.PainPointModel, prompt string, prices llm.Prices) models.UsageReport {
+	var report models.UsageReport
+	byUpload := map[string]*models.UsageSummary{}
+	byPrompt := map[string]*models.UsageSummary{}
+	byModel := map[string]*models.UsageSummary{}
+
+	count := func(output models.AiGenerated, uploadID *string) {
+		if output.Usage.TotalTokens == 0 || (prompt != "" && output.Prompt != prompt) {
+			return
+		}
+		cost := 0.0
+		if !output.Cached {
+			cost = prices.Cost(output.Model, output.Usage)
+		}
+		addUsage(&report.Total, output, cost)
+		if uploadID != nil {
+			addUsage(usageSummary(byUpload, *uploadID), output, cost)
+		}
+		addUsage(usageSummary(byPrompt, output.Prompt), output, cost)
+		addUsage(usageSummary(byModel, output.Model), output, cost)
+	}
+
+	for _, user := range userData {
+		outputs := []models.AiGenerated{user.AiOutput.AiResearch, user.AiOutput.ColdCalls, user.AiOutput.QuestionBasedEmail}
+		if user.CompanySummary != nil {
+			outputs = append(outputs, *user.CompanySummary)
+		}
+		for _, output := range outputs {
+			count(output, &user.UploadID)
+		}
+	}
+	for _, painPoint := range painPoints {
+		if painPoint.Generated != nil {
+			count(*painPoint.Generated, nil)
+		}
+	}
+
+	report.ByUpload = sortedSummaries(byUpload)
+	report.ByPrompt = sortedSummaries(byPrompt)
+	report.ByModel = sortedSummaries(byModel)
+	return report
+}
+
+func usageSummary(summaries map[string]*models.UsageSummary, key string) *models.UsageSummary {
+	if _, exists := summaries[key]; !exists {
+		summaries[key] = &models.UsageSummary{Key: key}
+	}
+	return summaries[key]
+}
+
+func addUsage(summary *models.UsageSummary, output models.AiGenerated, cost float64) {
+	summary.Calls++
+	if output.Cached {
+		summary.CachedCalls++
+		return
+	}
+	summary.PromptTokens += output.Usage.PromptTokens
+	summary.CompletionTokens += output.Usage.CompletionTokens
+	summary.TotalTokens += output.Usage.TotalTokens
+	summary.Cost += cost
+}
+
+func sortedSummaries(summaries map[string]*models.UsageSummary) []models.UsageSummary {
+	sorted := make([]models.UsageSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		sorted = append(sorted, *summary)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cost > sorted[j].Cost })
+	return sorted
+}
@@ -4,21 +4,21 @@ import (
 	"aiagent/models"
 	"aiagent/repository"
 	"aiagent/responses"
-	"aiagent/services"
-	"bytes"
+	"aiagent/services/llm"
+	"aiagent/services/scraper"
+	"aiagent/services/spreadsheet"
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"net/http"
 	"strings"
 
-	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
@@ -29,12 +29,13 @@ import (
 // UploadExcel				godoc
 // @Tags					UserData Apis
 // @Summary					Upload Excel File
-// @Description				Upload Excel File in Base 64 format
+// @Description				Upload Excel File in Base 64 format, .csv and .tsv files are accepted too. The sheet and the column mapping profile can be selected. The rows are validated and processed in the background, the returned upload job tracks the progress. A dry run only returns the validation report.
 // @Param metadata body models.UploadRequest true "File metadata"
 // @Produce					application/json
-// @Success					200 {object} responses.ApplicationResponse{}
+// @Success					200 {object} responses.ApplicationResponse{data=models.UploadReport}
+// @Success					202 {object} responses.ApplicationResponse{data=models.UploadJob}
 // @Router					/initializ/v1/ai/upload [POST]
-func UploadExcel(userDataRepo repository.Repository, promptRepo repository.Repository, painPointRepo repository.Repository) gin.HandlerFunc {
+func UploadExcel(promptRepo repository.Repository, mappingRepo repository.Repository, pipeline *UploadPipeline) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req models.UploadRequest
 		if err := ctx.BindJSON(&req); err != nil {
@@ -54,132 +55,318 @@ func UploadExcel(userDataRepo repository.Repository, promptRepo repository.Repos
 			})
 			return
 		}
-		excel, err := excelize.OpenReader(bytes.NewReader(data))
+		file := uploadedFile{Data: data, Sheet: req.Sheet, MappingProfile: req.MappingProfile}
+		options := uploadOptions{BypassCache: req.BypassCache, ForceRefresh: req.ForceRefresh, DryRun: req.DryRun}
+		startUpload(ctx, promptRepo, mappingRepo, pipeline, file, options)
+	}
+}
+
+// UploadFile				godoc
+// @Tags					UserData Apis
+// @Summary					Upload File
+// @Description				Upload a .xlsx, .csv or .tsv file as multipart form data. The format is detected from the content and the file name. The rows are validated and processed in the background, the returned upload job tracks the progress. A dry run only returns the validation report.
+// @Accept					multipart/form-data
+// @Param					file formData file true "Prospects file"
+// @Param					sheet formData string false "Sheet name or 1-based position, Sheet1 or the first sheet by default"
+// @Param					mapping_profile formData string false "Name or ID of a saved column mapping"
+// @Param					bypass_cache formData bool false "Regenerate every output even when an identical completion is cached"
+// @Param					force_refresh formData bool false "Scrape every page again even when a cached page exists"
+// @Param					dry_run formData bool false "Validate the rows and return the report without saving or generating anything"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{data=models.UploadReport}
+// @Success					202 {object} responses.ApplicationResponse{data=models.UploadJob}
+// @Router					/initializ/v1/ai/upload/file [POST]
+func UploadFile(promptRepo repository.Repository, mappingRepo repository.Repository, pipeline *UploadPipeline) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		file, status, err := readFormFile(ctx)
 		if err != nil {
-			log.Error("Failed to Read Excel Sheet", err)
-			ctx.JSON(http.StatusBadRequest, responses.ApplicationResponse{
-				Status:  http.StatusBadRequest,
-				Message: err.Error(),
-			})
+			ReturnResponse(ctx, status, err.Error(), nil)
 			return
 		}
-		// Reading header row to get the column names
-		rows := excel.GetRows("Sheet1")
-		headerRow := rows[0]
-		headerMap := make(map[string]int)
-		for idx, header := range headerRow {
-			headerMap[strings.ToLower(strings.TrimSpace(header))] = idx
+		options := uploadOptions{
+			BypassCache:  formBool(ctx, "bypass_cache"),
+			ForceRefresh: formBool(ctx, "force_refresh"),
+			DryRun:       formBool(ctx, "dry_run"),
 		}
+		startUpload(ctx, promptRepo, mappingRepo, pipeline, *file, options)
+	}
+}
 
-		for i, row := range excel.GetRows("Sheet1") {
-			if i == 0 {
+// PreviewUpload				godoc
+// @Tags					UserData Apis
+// @Summary					Preview Upload
+// @Description				Show the sheets, the detected headers, the proposed column mapping and the first prospects of a file without importing it
+// @Accept					multipart/form-data
+// @Param					file formData file true "Prospects file"
+// @Param					sheet formData string false "Sheet name or 1-based position, Sheet1 or the first sheet by default"
+// @Param					mapping_profile formData string false "Name or ID of a saved column mapping"
+// @Produce					application/json
+// @Success					200 {object} responses.ApplicationResponse{data=models.UploadPreview}
+// @Router					/initializ/v1/ai/upload/preview [POST]
+func PreviewUpload(mappingRepo repository.Repository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		file, status, err := readFormFile(ctx)
+		if err != nil {
+			ReturnResponse(ctx, status, err.Error(), nil)
+			return
+		}
+		sheet, err := readProspectSheet(mappingRepo, *file)
+		if err != nil {
+			ReturnResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		preview := models.UploadPreview{
+			Format:     sheet.Workbook.Format,
+			Sheets:     sheet.Workbook.Sheets,
+			Sheet:      sheet.Name,
+			Headers:    sheet.Headers,
+			SampleRows: []models.UserDetails{},
+		}
+		for _, field := range prospectFields {
+			index, mapped := sheet.Columns[field]
+			if !mapped {
+				preview.MissingFields = append(preview.MissingFields, field)
 				continue
-			} else {
-				// Prepareing the user details
-				user := models.UserDetails{}
-
-				// Dynamically map columns to UserDetails struct fields
-				if index, exists := headerMap["name"]; exists {
-					user.Name = strings.TrimSpace(row[index])
-					log.Print(user.Name)
-				}
-				if index, exists := headerMap["experience"]; exists {
-					user.Experience = row[index]
-				}
-				if index, exists := headerMap["location"]; exists {
-					user.Location = row[index]
-				}
-				if index, exists := headerMap["mobile no"]; exists {
-					user.MobileNo = row[index]
-				}
-				if index, exists := headerMap["email"]; exists {
-					user.Email = row[index]
-				}
-				if index, exists := headerMap["designation"]; exists {
-					user.Designation = strings.TrimSpace(row[index])
-					log.Print(user.Designation)
-				}
-				if index, exists := headerMap["company"]; exists {
-					user.CompanyDetails = row[index]
-				}
-				if index, exists := headerMap["linkedin url"]; exists {
-					user.LinkedInProfileUrl = row[index]
-				}
-				if index, exists := headerMap["company url"]; exists {
-					user.CompanyWebsite = row[index]
-				}
-
-				var wg sync.WaitGroup
-				wg.Add(2)
-				go func() {
-					defer wg.Done()
-					linkedin := strings.Replace(user.LinkedInProfileUrl, "www.linkedin.com", "in.linkedin.com", 1)
-					log.Print(linkedin)
-					linkedinData, err := services.ScrapeData(linkedin)
-					if err != nil {
-						log.Warn("Error fetching LinkedIn data for", user.Name, ":", err)
-					} else {
-						user.LinkedInProfileData = linkedinData
-					}
-				}()
-
-				var companyUrl string
-
-				// If the company website exists, use it
-				if len(user.CompanyWebsite) > 0 {
-					companyUrl = user.CompanyWebsite
-				} else {
-					// Otherwise, construct the URL using the email domain
-					parts := strings.Split(user.Email, "@")
-					if len(parts) > 1 {
-						companyUrl = "https://www." + parts[1]
-					}
-				}
-
-				// Scrape company data using the URL
-				go func() {
-					defer wg.Done()
-					if len(companyUrl) > 0 {
-						companyDescription, err := services.ScrapeData(companyUrl)
-						if err != nil {
-							log.Warn("Error fetching company data for", user.CompanyDetails, ":", err)
-						} else {
-							user.CompanyResearchedData = companyDescription
-						}
-					}
-				}()
-				wg.Wait()
-				// Fetch prompts from the database
-				prompts, err := fetchPrompts()
-				if err != nil {
-					log.Error("Error fetching prompts:", err)
-					ctx.JSON(http.StatusInternalServerError, responses.ApplicationResponse{
-						Status:  http.StatusInternalServerError,
-						Message: "Error fetching prompts from the database",
-					})
-					return
-				}
-				// Generate AI Output for the user
-				userAiOutput := generateAiOutput(user, prompts, painPointRepo)
-				user.AiOutput = userAiOutput
-
-				_, err = userDataRepo.InsertOne(user)
-				if err != nil {
-					log.Error("Error occurred while inserting user data:", err)
-				}
 			}
+			preview.Columns = append(preview.Columns, models.ColumnMatch{Field: field, Header: sheet.Headers[index], Column: index})
+		}
+		mappedColumns := make(map[int]bool)
+		for _, index := range sheet.Columns {
+			mappedColumns[index] = true
+		}
+		for index, header := range sheet.Headers {
+			if !mappedColumns[index] && strings.TrimSpace(header) != "" {
+				preview.UnmappedHeaders = append(preview.UnmappedHeaders, header)
+			}
+		}
+		rows := sheet.prospectRows()
+		preview.RowCount = len(rows)
+		for _, row := range rows[:min(len(rows), previewRows)] {
+			preview.SampleRows = append(preview.SampleRows, row.User)
 		}
+		ReturnResponse(ctx, http.StatusOK, "Successfully read the file", preview)
+	}
+}
+
+// previewRows is the number of prospects shown by the upload preview
+const previewRows = 5
 
-		log.Info("Data uploaded successfully")
+// maxUploadFileSize limits the size of multipart uploads
+const maxUploadFileSize = 20 << 20
+
+// uploadedFile is a file to import with the sheet and mapping to read it with
+type uploadedFile struct {
+	Data           []byte
+	Filename       string
+	Sheet          string
+	MappingProfile string
+}
+
+// readFormFile reads the multipart file and its sheet and mapping_profile
+// fields, the status code to answer with is returned with the error
+func readFormFile(ctx *gin.Context) (*uploadedFile, int, error) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("The file form field is required: %w", err)
+	}
+	if fileHeader.Size > maxUploadFileSize {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("The file is larger than %d MB", maxUploadFileSize>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Error opening the file: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Error reading the file: %w", err)
+	}
+	return &uploadedFile{
+		Data:           data,
+		Filename:       fileHeader.Filename,
+		Sheet:          ctx.PostForm("sheet"),
+		MappingProfile: ctx.PostForm("mapping_profile"),
+	}, http.StatusOK, nil
+}
+
+// formBool reads a boolean form field, absent or invalid values are false
+func formBool(ctx *gin.Context, key string) bool {
+	value, _ := strconv.ParseBool(ctx.PostForm(key))
+	return value
+}
+
+// prospectSheet is the selected sheet of an upload with its column mapping
+type prospectSheet struct {
+	Workbook *spreadsheet.Workbook
+	Name     string
+	Headers  []string
+	Rows     [][]string
+	Columns  spreadsheet.Columns
+}
+
+// readProspectSheet opens the file, selects the sheet and maps its header
+// row with the built-in aliases and those of the mapping profile
+func readProspectSheet(mappingRepo repository.Repository, file uploadedFile) (*prospectSheet, error) {
+	workbook, err := spreadsheet.Open(file.Data, file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	name, err := workbook.SheetName(file.Sheet)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := workbook.Rows(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("sheet %s has no header row", name)
+	}
+	aliases, err := columnAliases(mappingRepo, file.MappingProfile)
+	if err != nil {
+		return nil, err
+	}
+	return &prospectSheet{
+		Workbook: workbook,
+		Name:     name,
+		Headers:  rows[0],
+		Rows:     rows[1:],
+		Columns:  spreadsheet.MatchColumns(rows[0], prospectFields, aliases),
+	}, nil
+}
+
+// prospectRows maps every row to a prospect, blank rows are dropped
+func (s *prospectSheet) prospectRows() []uploadRow {
+	var rows []uploadRow
+	for i, row := range s.Rows {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		// The header is row 1
+		rows = append(rows, uploadRow{Number: i + 2, User: mapProspect(s.Columns, row)})
+	}
+	return rows
+}
+
+// startUpload maps the rows of an uploaded file to prospects and starts the upload job
+func startUpload(ctx *gin.Context, promptRepo repository.Repository, mappingRepo repository.Repository, pipeline *UploadPipeline, file uploadedFile, options uploadOptions) {
+	sheet, err := readProspectSheet(mappingRepo, file)
+	if err != nil {
+		log.Error("Failed to Read Excel Sheet", err)
+		ctx.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+			Status:  http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	if missing := missingRequiredFields(sheet.Columns); len(missing) > 0 {
+		ctx.JSON(http.StatusBadRequest, responses.ApplicationResponse{
+			Status:  http.StatusBadRequest,
+			Message: "The file has no column for " + strings.Join(missing, ", ") + ", rename the headers or select a mapping profile",
+		})
+		return
+	}
+	rows := sheet.prospectRows()
+
+	if options.DryRun {
+		report, err := newUploadReport(rows)
+		if err != nil {
+			log.Error("Error creating the errors sheet:", err)
+			ctx.JSON(http.StatusInternalServerError, responses.ApplicationResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "Error creating the errors sheet",
+			})
+			return
+		}
 		ctx.JSON(http.StatusOK, responses.ApplicationResponse{
 			Status:  http.StatusOK,
-			Message: "Data uploaded and AI output generated successfully",
+			Message: "Dry run completed, nothing was imported",
+			Data:    report,
 		})
+		return
 	}
+
+	// Fetch prompts from the database
+	prompts, err := fetchPrompts(promptRepo)
+	if err != nil {
+		log.Error("Error fetching prompts:", err)
+		ctx.JSON(http.StatusInternalServerError, responses.ApplicationResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "Error fetching prompts from the database",
+		})
+		return
+	}
+
+	job, err := pipeline.Start(rows, prompts, options)
+	if err != nil {
+		log.Error("Error creating the upload job:", err)
+		ctx.JSON(http.StatusInternalServerError, responses.ApplicationResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "Error creating the upload job",
+		})
+		return
+	}
+
+	log.Info("Upload job ", job.ID, " created for ", job.RowsTotal, " rows")
+	ctx.JSON(http.StatusAccepted, responses.ApplicationResponse{
+		Status:  http.StatusAccepted,
+		Message: "Upload accepted, the rows are processed in the background",
+		Data:    job,
+	})
+}
+
+// companySummaryPrompt asks the model to condense the crawled company pages
+const companySummaryPrompt = `You are a B2B sales researcher. Summarize the company website below for a sales representative preparing outreach.
+Cover what the company does, its products or solutions, the customers and industries it serves, recent news and what its hiring says about its priorities.
+Only use facts found in the pages, skip sections with no information and answer in at most 300 words.`
+
+// companySummaryName is the prompt name the company summaries are reported under
+const companySummaryName = "Company Summary"
+
+// summarizeCompany condenses the crawled pages of a company website and records
+// the model call. The combined page content is kept when the model fails.
+func summarizeCompany(ctx context.Context, llmClient llm.Client, site *scraper.Site, bypassCache bool) (string, *models.AiGenerated) {
+	temperature := float32(0.2)
+	modelConfig := newModelConfig(models.ModelSettings{Temperature: &temperature, MaxTokens: 600}, companySummaryPrompt, "")
+	modelConfig.BypassCache = bypassCache
+	llm.RenderPrompt(llmClient, &modelConfig, "Company website "+site.URL+"\n\n**company_pages**", []llm.Placeholder{
+		{Key: "**company_pages**", Value: site.Content, Trimmable: true},
+	})
+	completion, err := llmClient.Complete(ctx, modelConfig)
+	if err != nil {
+		log.Warn("Error summarizing the company website ", site.URL, ": ", err)
+		return site.Content, &models.AiGenerated{Prompt: companySummaryName, GeneratedAt: time.Now(), Error: err.Error()}
+	}
+	summary := newAiGenerated(companySummaryName, completion)
+	return completion.Content, &summary
+}
+
+// newScrapeError records why a page of the prospect could not be scraped
+func newScrapeError(source string, url string, err error) *models.ScrapeError {
+	return &models.ScrapeError{
+		Source:  source,
+		URL:     url,
+		Kind:    string(scraper.KindOf(err)),
+		Message: err.Error(),
+	}
+}
+
+// scrapeStatus returns the status code reported for a failed scrape
+func scrapeStatus(err error) int {
+	switch scraper.KindOf(err) {
+	case scraper.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case scraper.ErrUpstream:
+		return http.StatusBadGateway
+	}
+	return http.StatusBadRequest
 }
 
-// fetchPrompts retrieves the saved prompts from the database for AI generation
-func fetchPrompts() (map[string]models.Prompts, error) {
+// fetchPrompts retrieves the saved prompts from the database for AI generation.
+// A saved prompt with the same name as a built-in one replaces it, empty
+// prompt texts keep the built-in text so only the model settings can be changed.
+func fetchPrompts(promptRepo repository.Repository) (map[string]models.Prompts, error) {
 
 	promptMap := map[string]models.Prompts{
 		"Cold Calls": {
@@ -195,14 +382,61 @@ func fetchPrompts() (map[string]models.Prompts, error) {
 			PromptRule: "Tone: Informal, conversational, and non-salesy.Length: Maximum 100 words, preferably under 90.Personalization: Ensure all content is highly relevant and tailored to first_name's specific role, industry, and current situation. Demonstrate a deep understanding of their challenges and priorities.Language:Prioritize 'you' language to focus on the prospect.Use language and metrics hyper-specific to first_name 's job function and challenges.Avoid generic AI buzzwords, overly technical jargon, and generic industry trends.Content:Focus more on the prospect's company than on sender_company.Avoid phrases like 'At company' or 'I hope this message finds you well.'Don't use flattery or over-complimentary language (e.g., 'truly impressive,' 'truly remarkable').Omit any references to working with similar brands or social proof.Structure:Use line breaks between sentences for readability.Don't use company name suffixes (LTD, PLC, INC).Do not:Describe your own feelings. Instead, provide a descriptive perspective.Offer invitations (e.g., for drinks) in the P.S. line.Mention the weather.List sources or reference the research process.Demonstrate a nuanced understanding of **first_name**'s current processes and how sender_company  can improve them. Do not write a greetingUse more 'you' language.Never say At company Reference more about the company than us.Put a lot of whitespace between each sentence, which is a line gap, so it looks spaced outDo not put any company name suffixes like LTD, PLC, INC, you are writing an email to first_name who works at company . The email should be maximum 120 words. Under 100 words is preferable.Never say - I hope this message finds you well. Do not list sources. Use social intelligence to write a professional and succinct email. Do not describe how you feel. Instead provide a descriptive perspective. For example, avoid flattery and being over-complimentary. For example, 'truly impressive', 'truly remarkable', 'truly game-changer', 'truly inspiring', and similar should be completely avoided. ",
 		},
 	}
+
+	names := make([]string, 0, len(promptMap))
+	for name := range promptMap {
+		names = append(names, name)
+	}
+	// Oldest first so the most recently updated prompt wins
+	findOptions := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}})
+	cursor, err := promptRepo.FindWithOption(bson.M{"name": bson.M{"$in": names}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var savedPrompts []models.Prompts
+	if err := cursor.All(context.TODO(), &savedPrompts); err != nil {
+		return nil, err
+	}
+	for _, saved := range savedPrompts {
+		builtIn := promptMap[saved.Name]
+		if saved.Prompt == "" {
+			saved.Prompt = builtIn.Prompt
+		}
+		if saved.PromptRule == "" {
+			saved.PromptRule = builtIn.PromptRule
+		}
+		promptMap[saved.Name] = saved
+	}
 	return promptMap, nil
 }
 
-// generates AI outputs for Cold Calls, AI Research, and Question-Based Email using fetched prompts
-func generateAiOutput(user models.UserDetails, prompts map[string]models.Prompts, painPointRepo repository.Repository) models.UserAiOutput {
-	aiResearchOutput, _ := performResearchUsingPrompt(replacePlaceholders(prompts["AI Research"].Prompt, user, painPointRepo), prompts["AI Research"].PromptRule)
+// generateAiOutput runs the prompts for the user. Outputs that fail keep their
+// error and the failures are returned joined, the other outputs are still set.
+func generateAiOutput(ctx context.Context, llmClient llm.Client, user models.UserDetails, prompts map[string]models.Prompts, painPointRepo repository.Repository, bypassCache bool) (models.UserAiOutput, error) {
+	valueProposition, _ := GetPainPointsForRole(ctx, llmClient, painPointRepo, user.Designation)
 
-	var coldCallOutput, questionBasedEmailOutput string
+	generate := func(name string) models.AiGenerated {
+		completion, truncated, err := performResearchUsingPrompt(ctx, llmClient, prompts[name], promptPlaceholders(user, valueProposition), bypassCache)
+		if len(truncated) > 0 {
+			log.Warn("Prompt ", name, " for ", user.Name, " was truncated to fit the model: ", truncated)
+		}
+		if err != nil {
+			log.Error("Error generating ", name, " for ", user.Name, ": ", err)
+			return models.AiGenerated{Prompt: name, GeneratedAt: time.Now(), TruncatedPlaceholders: truncated, Error: err.Error()}
+		}
+		generated := newAiGenerated(name, completion)
+		generated.AiGeneratedOutpt = completion.Content
+		generated.TruncatedPlaceholders = truncated
+		return generated
+	}
+
+	aiResearchOutput := generate("AI Research")
+	// The other prompts build on the research
+	user.AiOutput.AiResearch = aiResearchOutput
+
+	var coldCallOutput, questionBasedEmailOutput models.AiGenerated
 
 	//GoRoutines
 	var wg sync.WaitGroup
@@ -211,35 +445,57 @@ func generateAiOutput(user models.UserDetails, prompts map[string]models.Prompts
 	// Cold Calls task
 	go func() {
 		defer wg.Done()
-		coldCallOutput, _ = performResearchUsingPrompt(replacePlaceholders(prompts["Cold Calls"].Prompt, user, painPointRepo), prompts["Cold Calls"].PromptRule)
+		coldCallOutput = generate("Cold Calls")
 	}()
 
 	// Question Based Email task
 	go func() {
 		defer wg.Done()
-		questionBasedEmailOutput, _ = performResearchUsingPrompt(replacePlaceholders(prompts["Question Based Email"].Prompt, user, painPointRepo), prompts["Question Based Email"].PromptRule)
+		questionBasedEmailOutput = generate("Question Based Email")
 	}()
 
 	wg.Wait()
 
-	return models.UserAiOutput{
-		ColdCalls: models.AiGenerated{
-			AiGeneratedOutpt: coldCallOutput,
-			GeneratedAt:      time.Now(),
-		},
-		AiResearch: models.AiGenerated{
-			AiGeneratedOutpt: aiResearchOutput,
-			GeneratedAt:      time.Now(),
-		},
-		QuestionBasedEmail: models.AiGenerated{
-			AiGeneratedOutpt: questionBasedEmailOutput,
-			GeneratedAt:      time.Now(),
-		},
+	output := models.UserAiOutput{
+		ColdCalls:          coldCallOutput,
+		AiResearch:         aiResearchOutput,
+		QuestionBasedEmail: questionBasedEmailOutput,
 	}
+	var failures []string
+	for _, generated := range []models.AiGenerated{output.AiResearch, output.ColdCalls, output.QuestionBasedEmail} {
+		if generated.Error != "" {
+			failures = append(failures, generated.Prompt+": "+generated.Error)
+		}
+	}
+	if len(failures) > 0 {
+		return output, errors.New(strings.Join(failures, "; "))
+	}
+	return output, nil
 }
 
-// replacing placeholders in the prompt with actual user data
-func replacePlaceholders(prompt string, user models.UserDetails, painPointRepo repository.Repository) string {
+// newAiGenerated records which model answered a prompt and the usage of the call
+func newAiGenerated(prompt string, completion *llm.Completion) models.AiGenerated {
+	return models.AiGenerated{
+		Prompt:      prompt,
+		GeneratedAt: time.Now(),
+		Provider:    completion.Provider,
+		Model:       completion.Model,
+		Usage:       completion.Usage,
+		Cached:      completion.Cached,
+	}
+}
+
+// generatedAny tells whether at least one prompt produced an output
+func generatedAny(output models.UserAiOutput) bool {
+	return output.AiResearch.Error == "" || output.ColdCalls.Error == "" || output.QuestionBasedEmail.Error == ""
+}
+
+// senderCompanyDetails describes Initializ.ai for the **sendercompanydetails** placeholder
+const senderCompanyDetails = "Initializ.ai appears to be a comprehensive platform offering solutions for developing, securing, and operating cloud-native and AI applications. Here's an overview of their services and the challenges they address: 1. Unified Platform for GenAI & Cloud-Native Apps: - Challenge: Complexity in managing the entire lifecycle of modern applications - Solution: Provides an all-in-one platform for building, deploying, and observing cloud-native and AI apps 2. Security Features: - Challenge: Ensuring application and infrastructure security throughout the development process - Solutions: a) Secure Container Building: Reduces attack surface, implements image signing and provenance validation b) Continuous Scanning & Remediation: Performs vulnerability scanning and auto-remediation for new CVEs c) AI-Driven Threat Management: Includes exploit probability assessment, vulnerability scanning, and compliance enforcement 3. Deployment Capabilities: - Challenge: Streamlining the deployment process across environments - Solutions: a) Instant Deployments: Supports deployment on their cloud, customer's cloud (BYOC), or on-premises b) Source Code to Running App: Automates building and running applications across TEST, STAGE & PROD environments c) Polyglot Support: Handles multiple languages and frameworks (Python, NodeJS, Java, Go & .NET) 4. AI Augmented Development: - Challenge: Enhancing developer productivity and integrating AI into the development process - Solutions: a) AI Augmented Development Environment b) Tooling integration with popular dev frameworks and IDEs c) Streamlined deployment workflows 5. Private AI Services: - Challenge: Deploying and managing AI models and services - Solutions: a) Support for various models (Llama 3, Whisper, Stable Diffusion, Custom Generative AI Models, LLMs) b) Pre-built AI inference apps c) Easy creation of new inference endpoints d) GPU and CPU fractioning for cost efficiency 6. Observability & AI-Ops: - Challenge: Monitoring and optimizing application performance - Solutions: a) Centralized logs, metrics & traces b) Intelligent monitoring & anomaly detection c) Predictive Analytics d) Auto Performance Improvement 7. Kubernetes Complexity Simplification: - Challenge: Managing the complexities of Kubernetes - Solution: Streamlined Kubernetes management (specific details not provided) 8. Collaboration and Reporting: - Challenge: Improving team collaboration and insights - Solutions: a) Advanced Reporting b) Alerts & Notifications c) Self-service capabilities d) Forecasting e) Data Import & Export While the provided information doesn't include specific quantitative data, Initializ.ai claims to offer significant benefits such as: - Reducing deployment failures and change failure rates - Improving application stability - Accelerating delivery times - Enabling faster experimentation The platform aims to streamline and simplify the entire application lifecycle, allowing development teams to focus on core business logic rather than infrastructure and operational concerns."
+
+// promptPlaceholders returns the values substituted into the prompts for a user.
+// Researched and descriptive values are trimmable so long prompts still fit the model.
+func promptPlaceholders(user models.UserDetails, valueProposition string) []llm.Placeholder {
 	firstName := ""
 	if len(user.Name) > 0 {
 		parts := strings.Fields(user.Name)
@@ -247,30 +503,28 @@ func replacePlaceholders(prompt string, user models.UserDetails, painPointRepo r
 			firstName = parts[0]
 		}
 	}
+	linkedinProfile := user.LinkedInProfileData
+	if linkedinProfile == "" {
+		linkedinProfile = user.LinkedInProfileUrl
+	}
 
-	valueProposition, err := GetPainPointsForRole(painPointRepo, user.Designation)
-	log.Print(err)
-	if err != nil {
-		return "At Initializ.ai, we provide a unified platform designed to streamline and simplify the entire lifecycle of cloud-native and AI applications. Our solutions address the complexity of managing modern application infrastructure while enhancing security, deployment efficiency, and developer productivity. Whether you're looking to build, secure, deploy, or optimize your applications, Initializ.ai offers an all-in-one platform that reduces operational overhead and accelerates innovation."
-	}
-
-	// Replace placeholders in the prompt
-	prompt = strings.Replace(prompt, "**first_name**", firstName, -1)
-	prompt = strings.Replace(prompt, "**title**", user.Designation, -1)
-	prompt = strings.Replace(prompt, "**Name**", user.Name, -1)
-	prompt = strings.Replace(prompt, "**Experience**", user.Experience, -1)
-	prompt = strings.Replace(prompt, "**Location**", user.Location, -1)
-	prompt = strings.Replace(prompt, "**company**", user.CompanyDetails, -1)
-	prompt = strings.Replace(prompt, "**linkedin_profile**", user.LinkedInProfileUrl, -1)
-	prompt = strings.Replace(prompt, "**company_website_data**", user.CompanyResearchedData, -1)
-	prompt = strings.Replace(prompt, "**sender_value_propositions**", valueProposition, -1)
-	prompt = strings.Replace(prompt, "**AI_Research**", user.AiOutput.AiResearch.AiGeneratedOutpt, -1)
-	prompt = strings.Replace(prompt, "**language**", "English", -1)
-	prompt = strings.Replace(prompt, "**tone**", "Conversational", -1)
-	prompt = strings.Replace(prompt, "**sender_company**", "initializ.ai", -1)
-	prompt = strings.Replace(prompt, "**sender_first_name**", "Yash", -1)
-	prompt = strings.Replace(prompt, "*sendercompanydetails**", "Initializ.ai appears to be a comprehensive platform offering solutions for developing, securing, and operating cloud-native and AI applications. Here's an overview of their services and the challenges they address: 1. Unified Platform for GenAI & Cloud-Native Apps: - Challenge: Complexity in managing the entire lifecycle of modern applications - Solution: Provides an all-in-one platform for building, deploying, and observing cloud-native and AI apps 2. Security Features: - Challenge: Ensuring application and infrastructure security throughout the development process - Solutions: a) Secure Container Building: Reduces attack surface, implements image signing and provenance validation b) Continuous Scanning & Remediation: Performs vulnerability scanning and auto-remediation for new CVEs c) AI-Driven Threat Management: Includes exploit probability assessment, vulnerability scanning, and compliance enforcement 3. Deployment Capabilities: - Challenge: Streamlining the deployment process across environments - Solutions: a) Instant Deployments: Supports deployment on their cloud, customer's cloud (BYOC), or on-premises b) Source Code to Running App: Automates building and running applications across TEST, STAGE & PROD environments c) Polyglot Support: Handles multiple languages and frameworks (Python, NodeJS, Java, Go & .NET) 4. AI Augmented Development: - Challenge: Enhancing developer productivity and integrating AI into the development process - Solutions: a) AI Augmented Development Environment b) Tooling integration with popular dev frameworks and IDEs c) Streamlined deployment workflows 5. Private AI Services: - Challenge: Deploying and managing AI models and services - Solutions: a) Support for various models (Llama 3, Whisper, Stable Diffusion, Custom Generative AI Models, LLMs) b) Pre-built AI inference apps c) Easy creation of new inference endpoints d) GPU and CPU fractioning for cost efficiency 6. Observability & AI-Ops: - Challenge: Monitoring and optimizing application performance - Solutions: a) Centralized logs, metrics & traces b) Intelligent monitoring & anomaly detection c) Predictive Analytics d) Auto Performance Improvement 7. Kubernetes Complexity Simplification: - Challenge: Managing the complexities of Kubernetes - Solution: Streamlined Kubernetes management (specific details not provided) 8. Collaboration and Reporting: - Challenge: Improving team collaboration and insights - Solutions: a) Advanced Reporting b) Alerts & Notifications c) Self-service capabilities d) Forecasting e) Data Import & Export While the provided information doesn't include specific quantitative data, Initializ.ai claims to offer significant benefits such as: - Reducing deployment failures and change failure rates - Improving application stability - Accelerating delivery times - Enabling faster experimentation The platform aims to streamline and simplify the entire application lifecycle, allowing development teams to focus on core business logic rather than infrastructure and operational concerns.", -1)
-	return prompt
+	return []llm.Placeholder{
+		{Key: "**first_name**", Value: firstName},
+		{Key: "**title**", Value: user.Designation},
+		{Key: "**Name**", Value: user.Name},
+		{Key: "**Experience**", Value: user.Experience},
+		{Key: "**Location**", Value: user.Location},
+		{Key: "**company**", Value: user.CompanyDetails},
+		{Key: "**linkedin_profile**", Value: linkedinProfile, Trimmable: true},
+		{Key: "**company_website_data**", Value: user.CompanyResearchedData, Trimmable: true},
+		{Key: "**sender_value_propositions**", Value: valueProposition, Trimmable: true},
+		{Key: "**AI_Research**", Value: user.AiOutput.AiResearch.AiGeneratedOutpt, Trimmable: true},
+		{Key: "**language**", Value: "English"},
+		{Key: "**tone**", Value: "Conversational"},
+		{Key: "**sender_company**", Value: "initializ.ai"},
+		{Key: "**sender_first_name**", Value: "Yash"},
+		{Key: "*sendercompanydetails**", Value: senderCompanyDetails, Trimmable: true},
+	}
 }
 
 // GetAllUserData			godoc
@@ -309,84 +563,77 @@ func GetAllUserData(userDataRepo repository.Repository) gin.HandlerFunc {
 		})
 	}
 }
-func performResearchUsingPrompt(prompt string, promptRule string) (string, error) {
-	modelUri := os.Getenv("MODELURI")
-	apiToken := os.Getenv("TOKEN")
-	if apiToken == "" {
-		return "", fmt.Errorf("bearer token not found. Please set the API token")
-	}
-
-	modelConfig := models.ModelConfig{
-		Model:     "meta-llama/Meta-Llama-3.1-8B-Instruct",
-		MaxTokens: 5000,
-		Stream:    false,
-		Messages: []models.Message{
-			{Role: "system", Content: promptRule},
-			{Role: "user", Content: prompt},
-		},
-	}
 
-	jsonConfig, err := json.Marshal(modelConfig)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling config: %v", err)
-	}
-
-	// Prepare the HTTP request with Bearer token
-	req, err := http.NewRequest("POST", modelUri, bytes.NewBuffer(jsonConfig))
-	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+apiToken)
-	req.Header.Set("Content-Type", "application/json")
+// performResearchUsingPrompt renders the prompt within the context window of its model and
+// sends it with its rule and model settings to the model. The trimmed placeholders are returned.
+func performResearchUsingPrompt(ctx context.Context, llmClient llm.Client, prompt models.Prompts, placeholders []llm.Placeholder, bypassCache bool) (*llm.Completion, []string, error) {
+	modelConfig := newModelConfig(prompt.ModelSettings, prompt.PromptRule, "")
+	modelConfig.BypassCache = bypassCache
+	truncated := llm.RenderPrompt(llmClient, &modelConfig, prompt.Prompt, placeholders)
+	completion, err := llmClient.Complete(ctx, modelConfig)
+	return completion, truncated, err
+}
 
-	// Send the request
-	client := http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
+// painPointLocks serializes the generation of the pain points of a role
+var painPointLocks = keyedMutex{locks: make(map[string]*keyedLock)}
 
-	// Handle unsuccessful responses
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed with status %d: %s", resp.StatusCode, string(body))
-	}
+// keyedMutex hands out one lock per key and forgets the keys nobody holds
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
 
-	// Parse and extract the response content
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("error reading response body: %v", err)
-	}
+// keyedLock is the lock of a key and the number of callers holding or waiting for it
+type keyedLock struct {
+	sync.Mutex
+	holders int
+}
 
-	// Define the structured response format to capture the entire AI response
-	var aiResponse struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+// lock waits for the lock of the key and returns the function releasing it
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	entry, exists := k.locks[key]
+	if !exists {
+		entry = &keyedLock{}
+		k.locks[key] = entry
 	}
-
-	// Unmarshal the body into the structured response
-	if err := json.Unmarshal(body, &aiResponse); err != nil {
-		return "", fmt.Errorf("error parsing response: %v", err)
+	entry.holders++
+	k.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		k.mu.Lock()
+		entry.holders--
+		if entry.holders == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
 	}
+}
 
-	// Check if we have any valid content in the response
-	if len(aiResponse.Choices) > 0 && aiResponse.Choices[0].Message.Content != "" {
-		return aiResponse.Choices[0].Message.Content, nil
+// GetPainPointsForRole returns the stored value proposition for a role and
+// generates and stores it with the model when the role is not known yet
+func GetPainPointsForRole(ctx context.Context, llmClient llm.Client, painPointRepo repository.Repository, role string) (string, error) {
+	if strings.TrimSpace(role) == "" {
+		return "", nil
 	}
 
-	return "", fmt.Errorf("no response content found")
-}
-
-func GetPainPointsForRole(painPointRepo repository.Repository, role string) (string, error) {
+	// Rows processed concurrently with the same role generate its pain points once
+	unlock := painPointLocks.lock(role)
+	defer unlock()
 
 	var painPoint models.PainPointModel
 	err := painPointRepo.FindOne(bson.M{"role": role}).Decode(&painPoint)
 	if err != nil {
-		GeneratePainPointsUsingAI(role)
+		items, generated, err := GeneratePainPointsUsingAI(ctx, llmClient, role)
+		if err != nil {
+			return "", err
+		}
+		painPoint, err = SavePainPoints(painPointRepo, role, items, generated)
+		if err != nil {
+			return painPoint.ValueProposition, err
+		}
 	}
 
 	return painPoint.ValueProposition, nil
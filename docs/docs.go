@@ -116,12 +116,88 @@ const docTemplate = `{
                 }
             }
         },
-        "/initializ/v1/ai/generatewithAI": {
+        "/initializ/v1/ai/columnmappings": {
+            "get": {
+                "description": "Get the saved column mapping profiles and the built-in header aliases",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Get Column Mappings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ApplicationResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Generate with AI",
+                "description": "Create or replace the column mapping profile with the same name",
                 "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Save Column Mapping",
+                "parameters": [
+                    {
+                        "description": "Column mapping",
+                        "name": "mapping",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ColumnMapping"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ApplicationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/columnmappings/{id}": {
+            "delete": {
+                "description": "Delete a column mapping profile by ID",
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Delete Column Mapping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Column mapping ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ApplicationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/generatewithAI": {
+            "post": {
+                "description": "Generate with AI. Without stream the data is the answer as a chat completion JSON string, read the text from choices[0].message.content. With stream set the answer is sent as Server-Sent Events: \"delta\" events with the content, then a \"done\" event with the completion or an \"error\" event.",
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
                 "tags": [
                     "AIAgent Apis"
                 ],
@@ -141,7 +217,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.ApplicationResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
@@ -348,7 +436,7 @@ const docTemplate = `{
         },
         "/initializ/v1/ai/upload": {
             "post": {
-                "description": "Upload Excel File in Base 64 format",
+                "description": "Upload Excel File in Base 64 format, .csv and .tsv files are accepted too. The sheet and the column mapping profile can be selected. The rows are validated and processed in the background, the returned upload job tracks the progress. A dry run only returns the validation report.",
                 "produces": [
                     "application/json"
                 ],
@@ -371,7 +459,351 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.ApplicationResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/upload/file": {
+            "post": {
+                "description": "Upload a .xlsx, .csv or .tsv file as multipart form data. The format is detected from the content and the file name. The rows are validated and processed in the background, the returned upload job tracks the progress. A dry run only returns the validation report.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Upload File",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Prospects file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sheet name or 1-based position, Sheet1 or the first sheet by default",
+                        "name": "sheet",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name or ID of a saved column mapping",
+                        "name": "mapping_profile",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Regenerate every output even when an identical completion is cached",
+                        "name": "bypass_cache",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Scrape every page again even when a cached page exists",
+                        "name": "force_refresh",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate the rows and return the report without saving or generating anything",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/upload/jobs/{jobId}": {
+            "get": {
+                "description": "Get the status of an upload job: rows total, done, failed and skipped, the current phase and the estimated completion time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Get Upload Job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jobId",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadJob"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/upload/jobs/{jobId}/errors": {
+            "get": {
+                "description": "Download an xlsx sheet listing the issues found in the rows of an upload job, the rows that failed and the rows with outputs that could not be generated",
+                "produces": [
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Download Upload Job Errors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jobId",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/upload/jobs/{jobId}/rows": {
+            "get": {
+                "description": "Get the result of every row of an upload job, optionally filtered by status (pending, running, done, failed or skipped)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Get Upload Job Rows",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jobId",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Row status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.UploadJobRow"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/upload/preview": {
+            "post": {
+                "description": "Show the sheets, the detected headers, the proposed column mapping and the first prospects of a file without importing it",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "UserData Apis"
+                ],
+                "summary": "Preview Upload",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Prospects file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sheet name or 1-based position, Sheet1 or the first sheet by default",
+                        "name": "sheet",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name or ID of a saved column mapping",
+                        "name": "mapping_profile",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadPreview"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/initializ/v1/ai/usage": {
+            "get": {
+                "description": "Get token usage and cost of the generated outputs, company summaries and pain points, aggregated per upload, prompt and model. Pain points are shared by every prospect with the same role and are not part of an upload.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage Apis"
+                ],
+                "summary": "Get Token Usage and Cost",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include the prospects of this upload, pain points are left out",
+                        "name": "upload_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include the outputs of this prompt",
+                        "name": "prompt",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/responses.ApplicationResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UsageReport"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
@@ -410,38 +842,156 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "models.Casestudy": {
+        "models.AiGenerated": {
             "type": "object",
             "properties": {
-                "url": {
+                "aiGeneratedOutpt": {
                     "type": "string"
-                }
-            }
-        },
-        "models.GenerateAIBody": {
-            "type": "object",
-            "properties": {
-                "company_url": {
+                },
+                "cached": {
+                    "description": "Cached outputs were served from the completion cache and cost nothing",
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error is set when the output could not be generated",
                     "type": "string"
                 },
-                "linkedin_url": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "stream": {
-                    "type": "boolean"
+                "model": {
+                    "type": "string"
                 },
-                "system_prompt": {
+                "prompt": {
+                    "description": "Prompt is the name of the prompt that generated the output",
                     "type": "string"
                 },
-                "task": {
+                "provider": {
+                    "description": "Provider and Model that actually produced the output",
                     "type": "string"
                 },
-                "to_do_research": {
-                    "type": "boolean"
+                "truncatedPlaceholders": {
+                    "description": "TruncatedPlaceholders lists the values shortened to fit the context window",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.TokenUsage"
                 }
             }
         },
-        "models.PainPointRole": {
+        "models.Casestudy": {
+            "type": "object",
+            "properties": {
+                "force_refresh": {
+                    "description": "ForceRefresh scrapes the URL again even when a cached page exists",
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ColumnMapping": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "description": "Aliases lists per field the accepted headers, they are tried before the\nbuilt-in ones. Fields are name, experience, location, mobile no, email,\ndesignation, company, linkedin url and company url.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ColumnMatch": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "description": "Column is the 0-based index of the column",
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "header": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GenerateAIBody": {
+            "type": "object",
+            "properties": {
+                "bypass_cache": {
+                    "type": "boolean"
+                },
+                "company_url": {
+                    "type": "string"
+                },
+                "force_refresh": {
+                    "description": "ForceRefresh scrapes the LinkedIn URL again even when a cached page exists",
+                    "type": "boolean"
+                },
+                "linkedin_url": {
+                    "type": "string"
+                },
+                "max_tokens": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "persist": {
+                    "description": "Persist stores the final answer in the generated outputs collection",
+                    "type": "boolean"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "stop": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stream": {
+                    "type": "boolean"
+                },
+                "system_prompt": {
+                    "type": "string"
+                },
+                "task": {
+                    "type": "string"
+                },
+                "temperature": {
+                    "type": "number"
+                },
+                "to_do_research": {
+                    "type": "boolean"
+                },
+                "top_p": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PainPointRole": {
             "type": "object",
             "properties": {
                 "role": {
@@ -461,6 +1011,12 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "max_tokens": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -470,9 +1026,24 @@ const docTemplate = `{
                 "prompt_rule": {
                     "type": "string"
                 },
+                "provider": {
+                    "type": "string"
+                },
                 "purpose": {
                     "type": "string"
                 },
+                "stop": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "temperature": {
+                    "type": "number"
+                },
+                "top_p": {
+                    "type": "number"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -481,11 +1052,404 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ScrapeError": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "description": "Kind is one of not_found, blocked, disallowed, timeout, empty_content or upstream_failure",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is \"linkedin\" or \"company\"",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TokenUsage": {
+            "type": "object",
+            "properties": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "estimated": {
+                    "description": "Estimated is set when the provider did not report usage",
+                    "type": "boolean"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UploadIssue": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the spreadsheet row number, the header being row 1",
+                    "type": "integer"
+                },
+                "severity": {
+                    "description": "Severity is IssueError or IssueWarning",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UploadJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is set when the whole job failed",
+                    "type": "string"
+                },
+                "eta": {
+                    "description": "ETA is estimated from the pace of the rows processed so far",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issues": {
+                    "description": "Issues lists the rows or values that were skipped",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UploadIssue"
+                    }
+                },
+                "phase": {
+                    "description": "Phase is the phase of the row processed last",
+                    "type": "string"
+                },
+                "rows_done": {
+                    "type": "integer"
+                },
+                "rows_failed": {
+                    "type": "integer"
+                },
+                "rows_skipped": {
+                    "type": "integer"
+                },
+                "rows_total": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UploadJobRow": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the spreadsheet row number, the header being row 1",
+                    "type": "integer"
+                },
+                "scrape_errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScrapeError"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "UserID is the ID of the saved prospect",
+                    "type": "string"
+                }
+            }
+        },
+        "models.UploadPreview": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ColumnMatch"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "missing_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "row_count": {
+                    "description": "RowCount excludes the header row",
+                    "type": "integer"
+                },
+                "sample_rows": {
+                    "description": "SampleRows are the first prospects as they would be imported",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserDetails"
+                    }
+                },
+                "sheet": {
+                    "description": "Sheet is the sheet that would be imported",
+                    "type": "string"
+                },
+                "sheets": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unmapped_headers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.UploadReport": {
+            "type": "object",
+            "properties": {
+                "errors_file": {
+                    "description": "ErrorsFile is an xlsx sheet listing the issues in Base 64 format, empty without issues",
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UploadIssue"
+                    }
+                },
+                "rows_invalid": {
+                    "type": "integer"
+                },
+                "rows_total": {
+                    "type": "integer"
+                },
+                "rows_valid": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.UploadRequest": {
             "type": "object",
             "properties": {
+                "bypass_cache": {
+                    "description": "BypassCache regenerates every output even when an identical completion is cached",
+                    "type": "boolean"
+                },
+                "dry_run": {
+                    "description": "DryRun validates the rows and returns the report without saving or generating anything",
+                    "type": "boolean"
+                },
                 "file_data": {
                     "type": "string"
+                },
+                "force_refresh": {
+                    "description": "ForceRefresh scrapes every page again even when a cached page exists",
+                    "type": "boolean"
+                },
+                "mapping_profile": {
+                    "description": "MappingProfile is the name or ID of a saved column mapping",
+                    "type": "string"
+                },
+                "sheet": {
+                    "description": "Sheet is the name or 1-based position of the sheet to import, Sheet1 or the first sheet by default",
+                    "type": "string"
+                }
+            }
+        },
+        "models.UsageReport": {
+            "type": "object",
+            "properties": {
+                "by_model": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageSummary"
+                    }
+                },
+                "by_prompt": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageSummary"
+                    }
+                },
+                "by_upload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageSummary"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/models.UsageSummary"
+                }
+            }
+        },
+        "models.UsageSummary": {
+            "type": "object",
+            "properties": {
+                "cached_calls": {
+                    "type": "integer"
+                },
+                "calls": {
+                    "type": "integer"
+                },
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserAiOutput": {
+            "type": "object",
+            "properties": {
+                "aiResearch": {
+                    "$ref": "#/definitions/models.AiGenerated"
+                },
+                "coldCalls": {
+                    "$ref": "#/definitions/models.AiGenerated"
+                },
+                "questionBasedEmail": {
+                    "$ref": "#/definitions/models.AiGenerated"
+                }
+            }
+        },
+        "models.UserDetails": {
+            "type": "object",
+            "properties": {
+                "ai_output": {
+                    "$ref": "#/definitions/models.UserAiOutput"
+                },
+                "company": {
+                    "type": "string"
+                },
+                "company_data": {
+                    "type": "string"
+                },
+                "company_pages": {
+                    "description": "CompanyPages are the pages of the company website summarized into CompanyResearchedData",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "company_summary": {
+                    "description": "CompanySummary records the model call that summarized CompanyPages, the\nsummary itself is CompanyResearchedData",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AiGenerated"
+                        }
+                    ]
+                },
+                "company_website": {
+                    "type": "string"
+                },
+                "company_website_source": {
+                    "description": "CompanyWebsiteSource tells how CompanyWebsite was derived: column, email_domain or company_name",
+                    "type": "string"
+                },
+                "designation": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "experience": {
+                    "type": "string"
+                },
+                "linkedIn_data": {
+                    "type": "string"
+                },
+                "linkedin_url": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "mob_no": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scrape_errors": {
+                    "description": "ScrapeErrors tells why the LinkedIn or company data is missing",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScrapeError"
+                    }
+                },
+                "upload_id": {
+                    "description": "UploadID groups the prospects imported by the same upload",
+                    "type": "string"
                 }
             }
         },
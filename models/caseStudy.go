@@ -8,4 +8,6 @@ type CaseStudy struct {
 
 type Casestudy struct {
 	URL string `json:"url" bson:"url"`
+	// ForceRefresh scrapes the URL again even when a cached page exists
+	ForceRefresh bool `json:"force_refresh,omitempty" bson:"-"`
 }
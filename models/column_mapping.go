@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ColumnMapping is a saved profile of the headers used by an import source
+type ColumnMapping struct {
+	ID   string `bson:"_id,omitempty" json:"id"`
+	Name string `bson:"name" json:"name"`
+	// Aliases lists per field the accepted headers, they are tried before the
+	// built-in ones. Fields are name, experience, location, mobile no, email,
+	// designation, company, linkedin url and company url.
+	Aliases   map[string][]string `bson:"aliases" json:"aliases"`
+	CreatedAt time.Time           `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt time.Time           `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// ColumnMatch is the column proposed for a field
+type ColumnMatch struct {
+	Field  string `json:"field"`
+	Header string `json:"header"`
+	// Column is the 0-based index of the column
+	Column int `json:"column"`
+}
+
+// UploadPreview shows how an upload would be read before it is imported
+type UploadPreview struct {
+	Format string   `json:"format"`
+	Sheets []string `json:"sheets"`
+	// Sheet is the sheet that would be imported
+	Sheet           string        `json:"sheet"`
+	Headers         []string      `json:"headers"`
+	Columns         []ColumnMatch `json:"columns"`
+	UnmappedHeaders []string      `json:"unmapped_headers,omitempty"`
+	MissingFields   []string      `json:"missing_fields,omitempty"`
+	// RowCount excludes the header row
+	RowCount int `json:"row_count"`
+	// SampleRows are the first prospects as they would be imported
+	SampleRows []UserDetails `json:"sample_rows"`
+}
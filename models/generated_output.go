@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// GeneratedOutput is a persisted answer of the generate with AI endpoint
+type GeneratedOutput struct {
+	ID           string     `bson:"_id,omitempty" json:"id"`
+	SystemPrompt string     `bson:"system_prompt" json:"system_prompt"`
+	Task         string     `bson:"task" json:"task"`
+	Content      string     `bson:"content" json:"content"`
+	Provider     string     `bson:"provider" json:"provider"`
+	Model        string     `bson:"model" json:"model"`
+	Usage        TokenUsage `bson:"usage" json:"usage"`
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+}
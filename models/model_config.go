@@ -1,13 +1,45 @@
 package models
 
 type ModelConfig struct {
+	// Provider selects the model provider, it is never sent upstream
+	Provider string `json:"-"`
+	// BypassCache forces a fresh completion, it is never sent upstream
+	BypassCache bool      `json:"-"`
 	Model       string    `json:"model"`
 	Stream      bool      `json:"stream"`
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float32   `json:"temperature,omitempty"`
-	TopP        float64   `json:"top_p,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty"`
 	TopK        float64   `json:"top_k,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+	// ResponseFormat asks the model for a JSON answer when supported
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// ModelSettings are the per prompt overrides applied to the model config,
+// empty values fall back to the configured defaults. Temperature and TopP are
+// pointers so a stored 0 is told apart from an unset value.
+type ModelSettings struct {
+	Provider    string   `bson:"provider,omitempty" json:"provider,omitempty"`
+	Model       string   `bson:"model,omitempty" json:"model,omitempty"`
+	Temperature *float32 `bson:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP        *float64 `bson:"top_p,omitempty" json:"top_p,omitempty"`
+	MaxTokens   int      `bson:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+	Stop        []string `bson:"stop,omitempty" json:"stop,omitempty"`
+}
+
+// TokenUsage is the number of tokens consumed by a model call
+type TokenUsage struct {
+	PromptTokens     int `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int `bson:"total_tokens" json:"total_tokens"`
+	// Estimated is set when the provider did not report usage
+	Estimated bool `bson:"estimated,omitempty" json:"estimated,omitempty"`
 }
 
 type Message struct {
@@ -3,13 +3,22 @@ package models
 import "time"
 
 type PainPointModel struct {
-	ID               string    `bson:"_id,omitempty" json:"id"`
-	Role             string    `json:"role" bson:"role"`
-	PainPoint        string    `json:"pain_points" bson:"pain_points"`
-	ValueProposition string    `json:"value_proposition" bson:"value_proposition"`
-	CreatedAt        time.Time `json:"created_at" bson:"created_at"`
+	ID               string `bson:"_id,omitempty" json:"id"`
+	Role             string `json:"role" bson:"role"`
+	PainPoint        string `json:"pain_points" bson:"pain_points"`
+	ValueProposition string `json:"value_proposition" bson:"value_proposition"`
+	// Items holds every pain point with its matching value proposition
+	Items     []PainPointItem `json:"items,omitempty" bson:"items,omitempty"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at"`
+	// Generated records the model call that generated the items
+	Generated *AiGenerated `json:"generated,omitempty" bson:"generated,omitempty"`
 }
 
 type PainPointRole struct {
 	Role string `json:"role" bson:"role"`
 }
+
+type PainPointItem struct {
+	PainPoint        string `json:"pain_point" bson:"pain_point"`
+	ValueProposition string `json:"value_proposition" bson:"value_proposition"`
+}
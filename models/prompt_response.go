@@ -14,6 +14,8 @@ type Prompts struct {
 	UpdatedAt  time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 	CreatedAt  time.Time `bson:"created_at,omitempty" json:"created_at,omitempty"`
 	UpdatedBy  string    `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	// Model settings used when generating with this prompt
+	ModelSettings `bson:",inline"`
 }
 
 type UserDetails struct {
@@ -29,6 +31,27 @@ type UserDetails struct {
 	CompanyResearchedData string       `bson:"company_data" json:"company_data"`
 	CompanyWebsite        string       `json:"company_website" bson:"company_website"`
 	AiOutput              UserAiOutput `bson:"ai_output" json:"ai_output"`
+	// UploadID groups the prospects imported by the same upload
+	UploadID string `bson:"upload_id,omitempty" json:"upload_id,omitempty"`
+	// CompanyPages are the pages of the company website summarized into CompanyResearchedData
+	CompanyPages []string `bson:"company_pages,omitempty" json:"company_pages,omitempty"`
+	// CompanyWebsiteSource tells how CompanyWebsite was derived: column, email_domain or company_name
+	CompanyWebsiteSource string `bson:"company_website_source,omitempty" json:"company_website_source,omitempty"`
+	// ScrapeErrors tells why the LinkedIn or company data is missing
+	ScrapeErrors []ScrapeError `bson:"scrape_errors,omitempty" json:"scrape_errors,omitempty"`
+	// CompanySummary records the model call that summarized CompanyPages, the
+	// summary itself is CompanyResearchedData
+	CompanySummary *AiGenerated `bson:"company_summary,omitempty" json:"company_summary,omitempty"`
+}
+
+// ScrapeError records a page of a prospect that could not be scraped
+type ScrapeError struct {
+	// Source is "linkedin" or "company"
+	Source string `bson:"source" json:"source"`
+	URL    string `bson:"url" json:"url"`
+	// Kind is one of not_found, blocked, disallowed, timeout, empty_content or upstream_failure
+	Kind    string `bson:"kind" json:"kind"`
+	Message string `bson:"message" json:"message"`
 }
 
 type GenerateAIBody struct {
@@ -38,4 +61,10 @@ type GenerateAIBody struct {
 	Stream       bool   `bson:"stream,omitempty" json:"stream,omitempty"`
 	Task         string `bson:"task,omitempty" json:"task,omitempty"`
 	TODOResearch bool   `bson:"to_do_research,omitempty" json:"to_do_research,omitempty"`
+	BypassCache  bool   `bson:"bypass_cache,omitempty" json:"bypass_cache,omitempty"`
+	// ForceRefresh scrapes the LinkedIn URL again even when a cached page exists
+	ForceRefresh bool `bson:"force_refresh,omitempty" json:"force_refresh,omitempty"`
+	// Persist stores the final answer in the generated outputs collection
+	Persist       bool `bson:"persist,omitempty" json:"persist,omitempty"`
+	ModelSettings `bson:",inline"`
 }
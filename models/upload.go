@@ -2,6 +2,45 @@ package models
 
 type UploadRequest struct {
 	FileData string `json:"file_data"`
+	// BypassCache regenerates every output even when an identical completion is cached
+	BypassCache bool `json:"bypass_cache,omitempty"`
+	// ForceRefresh scrapes every page again even when a cached page exists
+	ForceRefresh bool `json:"force_refresh,omitempty"`
+	// Sheet is the name or 1-based position of the sheet to import, Sheet1 or the first sheet by default
+	Sheet string `json:"sheet,omitempty"`
+	// MappingProfile is the name or ID of a saved column mapping
+	MappingProfile string `json:"mapping_profile,omitempty"`
+	// DryRun validates the rows and returns the report without saving or generating anything
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Severities of an upload issue
+const (
+	// IssueError rows are not imported
+	IssueError = "error"
+	// IssueWarning rows are imported without using the value
+	IssueWarning = "warning"
+)
+
+// UploadIssue describes a problem found in a row of an upload
+type UploadIssue struct {
+	// Row is the spreadsheet row number, the header being row 1
+	Row     int    `bson:"row" json:"row"`
+	Field   string `bson:"field" json:"field"`
+	Value   string `bson:"value,omitempty" json:"value,omitempty"`
+	Message string `bson:"message" json:"message"`
+	// Severity is IssueError or IssueWarning
+	Severity string `bson:"severity" json:"severity"`
+}
+
+// UploadReport is the result of a dry run
+type UploadReport struct {
+	RowsTotal   int           `json:"rows_total"`
+	RowsValid   int           `json:"rows_valid"`
+	RowsInvalid int           `json:"rows_invalid"`
+	Issues      []UploadIssue `json:"issues"`
+	// ErrorsFile is an xlsx sheet listing the issues in Base 64 format, empty without issues
+	ErrorsFile string `json:"errors_file,omitempty"`
 }
 
 type Users struct {
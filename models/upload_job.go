@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Statuses of an upload job and of its rows
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+
+	RowPending = "pending"
+	RowRunning = "running"
+	RowDone    = "done"
+	RowFailed  = "failed"
+	RowSkipped = "skipped"
+)
+
+// Phases a row goes through while it is processed
+const (
+	PhaseScraping    = "scraping"
+	PhaseSummarizing = "summarizing"
+	PhaseGenerating  = "generating"
+	PhaseSaving      = "saving"
+)
+
+// UploadJob tracks an upload processed in the background. Its ID is the
+// upload ID stored on the prospects.
+type UploadJob struct {
+	ID          string `bson:"_id" json:"id"`
+	Status      string `bson:"status" json:"status"`
+	RowsTotal   int    `bson:"rows_total" json:"rows_total"`
+	RowsDone    int    `bson:"rows_done" json:"rows_done"`
+	RowsFailed  int    `bson:"rows_failed" json:"rows_failed"`
+	RowsSkipped int    `bson:"rows_skipped" json:"rows_skipped"`
+	// Phase is the phase of the row processed last
+	Phase string `bson:"phase,omitempty" json:"phase,omitempty"`
+	// Issues lists the rows or values that were skipped
+	Issues []UploadIssue `bson:"issues,omitempty" json:"issues,omitempty"`
+	// Error is set when the whole job failed
+	Error      string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	StartedAt  *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	// ETA is estimated from the pace of the rows processed so far
+	ETA *time.Time `bson:"-" json:"eta,omitempty"`
+}
+
+// UploadJobRow is the result of a single row of an upload job
+type UploadJobRow struct {
+	ID    string `bson:"_id" json:"id"`
+	JobID string `bson:"job_id" json:"job_id"`
+	// Row is the spreadsheet row number, the header being row 1
+	Row    int    `bson:"row" json:"row"`
+	Name   string `bson:"name,omitempty" json:"name,omitempty"`
+	Email  string `bson:"email,omitempty" json:"email,omitempty"`
+	Status string `bson:"status" json:"status"`
+	Phase  string `bson:"phase,omitempty" json:"phase,omitempty"`
+	// UserID is the ID of the saved prospect
+	UserID       string        `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Error        string        `bson:"error,omitempty" json:"error,omitempty"`
+	ScrapeErrors []ScrapeError `bson:"scrape_errors,omitempty" json:"scrape_errors,omitempty"`
+	StartedAt    *time.Time    `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt   *time.Time    `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
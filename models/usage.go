@@ -0,0 +1,20 @@
+package models
+
+// UsageSummary aggregates the token usage and cost of a group of model calls
+type UsageSummary struct {
+	Key              string  `json:"key,omitempty"`
+	Calls            int     `json:"calls"`
+	CachedCalls      int     `json:"cached_calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageReport is the token usage and cost of the stored prospects
+type UsageReport struct {
+	Total    UsageSummary   `json:"total"`
+	ByUpload []UsageSummary `json:"by_upload"`
+	ByPrompt []UsageSummary `json:"by_prompt"`
+	ByModel  []UsageSummary `json:"by_model"`
+}
@@ -10,6 +10,18 @@ type UserAiOutput struct {
 	QuestionBasedEmail AiGenerated
 }
 type AiGenerated struct {
+	// Prompt is the name of the prompt that generated the output
+	Prompt           string
 	AiGeneratedOutpt string
 	GeneratedAt      time.Time
+	// Provider and Model that actually produced the output
+	Provider string
+	Model    string
+	// TruncatedPlaceholders lists the values shortened to fit the context window
+	TruncatedPlaceholders []string `bson:",omitempty" json:",omitempty"`
+	Usage                 TokenUsage
+	// Cached outputs were served from the completion cache and cost nothing
+	Cached bool
+	// Error is set when the output could not be generated
+	Error string `bson:",omitempty" json:",omitempty"`
 }
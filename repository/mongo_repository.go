@@ -17,6 +17,7 @@ type Repository interface {
 	InsertOne(document interface{}) (interface{}, error)
 	DeleteMany(filter primitive.M)
 	UpdateOne(filter primitive.M, update primitive.M, updateOptions *options.UpdateOptions) error
+	UpdateMany(filter primitive.M, update primitive.M) error
 	Find(filter primitive.M) (*mongo.Cursor, error)
 	FindWithOption(filter primitive.M, option *options.FindOptions) (*mongo.Cursor, error)
 	InsertMany(document []interface{}, insertOptions *options.InsertManyOptions) ([]interface{}, error)
@@ -77,6 +78,17 @@ func (m *MongoUserRepository) UpdateOne(filter primitive.M, update primitive.M,
 	return nil
 }
 
+func (m *MongoUserRepository) UpdateMany(filter primitive.M, update primitive.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := m.Collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error updating documents")
+		return errors.New("error updating documents")
+	}
+	return nil
+}
+
 func (m *MongoUserRepository) Find(filter primitive.M) (*mongo.Cursor, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
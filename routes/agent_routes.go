@@ -1,11 +1,12 @@
 package routes
 
 import (
+	"aiagent/config"
 	"aiagent/controllers"
 
 	"github.com/gin-gonic/gin"
 )
 
 func AgentRoutes(router *gin.Engine) {
-	router.POST("initializ/v1/ai/generatewithAI", controllers.GeneratewithAIHandler())
+	router.POST("initializ/v1/ai/generatewithAI", controllers.GeneratewithAIHandler(config.GetLLMClient(), config.GetScraper(), config.GetRepoCollection("GeneratedOutputs")))
 }
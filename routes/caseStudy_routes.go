@@ -10,7 +10,7 @@ import (
 func CaseStudyRoutes(router *gin.Engine) {
 	caseStudyRepo := config.GetRepoCollection("CaseStudy")
 
-	router.POST("/initializ/v1/ai/casestudy", controllers.SaveCaseStudy(caseStudyRepo))
+	router.POST("/initializ/v1/ai/casestudy", controllers.SaveCaseStudy(caseStudyRepo, config.GetScraper()))
 	router.GET("/initializ/v1/ai/casestudy", controllers.GetCaseStudy(caseStudyRepo))
 	router.DELETE("/initializ/v1/ai/casestudy/:id", controllers.DeleteCaseStudy(caseStudyRepo))
 }
@@ -9,8 +9,9 @@ import (
 
 func PainPointRoutes(router *gin.Engine) {
 	painPointRepo := config.GetRepoCollection("PainPoints")
+	llmClient := config.GetLLMClient()
 
 	router.GET("/initializ/v1/ai/painpoints", controllers.GetPainPoints(painPointRepo))
-	router.POST("/initializ/v1/ai/painpoints", controllers.SaveAiResponseToDB(painPointRepo))
+	router.POST("/initializ/v1/ai/painpoints", controllers.SaveAiResponseToDB(painPointRepo, llmClient))
 	router.DELETE("/initializ/v1/ai/painpoints/:id", controllers.DeletePainPoints(painPointRepo))
 }
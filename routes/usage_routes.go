@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"aiagent/config"
+	"aiagent/controllers"
+	"aiagent/services/llm"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UsageRoutes(router *gin.Engine) {
+	userDataRepo := config.GetRepoCollection("UserData")
+	painPointRepo := config.GetRepoCollection("PainPoints")
+
+	router.GET("/initializ/v1/ai/usage", controllers.GetUsage(userDataRepo, painPointRepo, llm.PricesFromEnv()))
+}
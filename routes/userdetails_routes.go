@@ -11,7 +11,30 @@ func UserDataRouter(router *gin.Engine) {
 	userDataRepo := config.GetRepoCollection("UserData")
 	promptRepo := config.GetRepoCollection("AIPrompts")
 	painPonitsRepo := config.GetRepoCollection("PainPoints")
-	router.POST("/initializ/v1/ai/upload", controllers.UploadExcel(userDataRepo, promptRepo, painPonitsRepo))
+	uploadJobRepo := config.GetRepoCollection("UploadJobs")
+	uploadJobRowRepo := config.GetRepoCollection("UploadJobRows")
+	mappingRepo := config.GetRepoCollection("ColumnMappings")
+	controllers.FailInterruptedUploadJobs(uploadJobRepo, uploadJobRowRepo)
+	pipeline := &controllers.UploadPipeline{
+		Workers:         config.GetUploadWorkers(),
+		UserDataRepo:    userDataRepo,
+		PainPointRepo:   painPonitsRepo,
+		JobRepo:         uploadJobRepo,
+		JobRowRepo:      uploadJobRowRepo,
+		LLMClient:       config.GetLLMClient(),
+		Scraper:         config.GetScraper(),
+		Crawler:         config.GetCrawler(),
+		WebsiteResolver: config.GetWebsiteResolver(),
+	}
+	router.POST("/initializ/v1/ai/upload", controllers.UploadExcel(promptRepo, mappingRepo, pipeline))
+	router.POST("/initializ/v1/ai/upload/file", controllers.UploadFile(promptRepo, mappingRepo, pipeline))
+	router.POST("/initializ/v1/ai/upload/preview", controllers.PreviewUpload(mappingRepo))
+	router.POST("/initializ/v1/ai/columnmappings", controllers.SaveColumnMapping(mappingRepo))
+	router.GET("/initializ/v1/ai/columnmappings", controllers.GetColumnMappings(mappingRepo))
+	router.DELETE("/initializ/v1/ai/columnmappings/:id", controllers.DeleteColumnMapping(mappingRepo))
+	router.GET("/initializ/v1/ai/upload/jobs/:jobId", controllers.GetUploadJob(uploadJobRepo))
+	router.GET("/initializ/v1/ai/upload/jobs/:jobId/rows", controllers.GetUploadJobRows(uploadJobRowRepo))
+	router.GET("/initializ/v1/ai/upload/jobs/:jobId/errors", controllers.DownloadUploadJobErrors(uploadJobRepo, uploadJobRowRepo))
 	router.GET("/initializ/v1/ai/allusers", controllers.GetAllUserData(userDataRepo))
 	router.DELETE("initializ/v1/ai/user/delete", controllers.DeleteUserDetails(userDataRepo))
 }
@@ -0,0 +1,120 @@
+package linkedin
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Kinds of LinkedIn URLs
+const (
+	KindProfile        = "profile"
+	KindCompany        = "company"
+	KindSalesNavigator = "sales_navigator"
+)
+
+// canonicalHost is the host of every canonical URL
+const canonicalHost = "www.linkedin.com"
+
+// scrapeHost serves the public profiles that are scraped, profiles have always
+// been fetched from this host
+const scrapeHost = "in.linkedin.com"
+
+// ErrInvalidURL is returned for URLs that are not LinkedIn profile, company or
+// Sales Navigator URLs
+var ErrInvalidURL = errors.New("invalid LinkedIn URL")
+
+// URL is a parsed LinkedIn URL
+type URL struct {
+	Kind string
+	// ID is the public identifier of a profile or company, or the lead or
+	// account ID of a Sales Navigator URL
+	ID string
+	// Canonical is the stable form of the URL, without locale subdomain,
+	// query, fragment or trailing path
+	Canonical string
+}
+
+// Parse validates a LinkedIn URL and returns its kind and canonical form.
+// The scheme may be omitted, locale and mobile subdomains such as
+// in.linkedin.com or m.linkedin.com are accepted.
+func Parse(rawURL string) (*URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: empty URL", ErrInvalidURL)
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidURL, parsed.Scheme)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host != "linkedin.com" && !strings.HasSuffix(host, ".linkedin.com") {
+		return nil, fmt.Errorf("%w: %s is not a LinkedIn host", ErrInvalidURL, host)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("%w: %s is not a profile or company page", ErrInvalidURL, parsed.Path)
+	}
+
+	switch strings.ToLower(segments[0]) {
+	case "in":
+		return newURL(KindProfile, "in", strings.ToLower(segments[1]))
+	case "pub":
+		// Old public profile URLs: /pub/<name>/<a>/<b>/<c>
+		return newURL(KindProfile, "pub", strings.ToLower(strings.Join(segments[1:min(len(segments), 5)], "/")))
+	case "company", "showcase":
+		return newURL(KindCompany, strings.ToLower(segments[0]), strings.ToLower(segments[1]))
+	case "sales":
+		// Sales Navigator URLs: /sales/lead/<id>,<search type>,<token> or /sales/company/<id>
+		if len(segments) < 3 {
+			break
+		}
+		section := strings.ToLower(segments[1])
+		if section != "lead" && section != "people" && section != "company" && section != "account" {
+			break
+		}
+		id, _, _ := strings.Cut(segments[2], ",")
+		return newURL(KindSalesNavigator, "sales/"+section, id)
+	}
+	return nil, fmt.Errorf("%w: %s is not a profile or company page", ErrInvalidURL, parsed.Path)
+}
+
+func newURL(kind string, prefix string, id string) (*URL, error) {
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		id = unescaped
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%w: missing identifier", ErrInvalidURL)
+	}
+	canonical := url.URL{Scheme: "https", Host: canonicalHost, Path: "/" + prefix + "/" + id}
+	return &URL{Kind: kind, ID: id, Canonical: canonical.String()}, nil
+}
+
+// Scrapable tells whether the page is public, Sales Navigator pages need a login
+func (u *URL) Scrapable() bool {
+	return u.Kind != KindSalesNavigator
+}
+
+// ScrapeURL is the URL the page is scraped from. Every caller uses it so a
+// profile shares one scrape cache entry and one politeness host.
+func (u *URL) ScrapeURL() string {
+	return u.WithHost(scrapeHost)
+}
+
+// WithHost returns the canonical URL served by another LinkedIn host
+func (u *URL) WithHost(host string) string {
+	return strings.Replace(u.Canonical, "://"+canonicalHost+"/", "://"+host+"/", 1)
+}
@@ -0,0 +1,80 @@
+package linkedin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw       string
+		kind      string
+		id        string
+		canonical string
+	}{
+		{"https://www.linkedin.com/in/ann-smith", KindProfile, "ann-smith", "https://www.linkedin.com/in/ann-smith"},
+		{"linkedin.com/in/Ann-Smith/", KindProfile, "ann-smith", "https://www.linkedin.com/in/ann-smith"},
+		{"  http://in.linkedin.com/in/ann-smith?trk=public#about ", KindProfile, "ann-smith", "https://www.linkedin.com/in/ann-smith"},
+		{"https://m.linkedin.com/in/ann-smith/details/experience/", KindProfile, "ann-smith", "https://www.linkedin.com/in/ann-smith"},
+		{"https://www.linkedin.com/in/j%C3%BCrgen", KindProfile, "jürgen", "https://www.linkedin.com/in/j%C3%BCrgen"},
+		{"https://www.linkedin.com/pub/ann-smith/1/2b/3c4", KindProfile, "ann-smith/1/2b/3c4", "https://www.linkedin.com/pub/ann-smith/1/2b/3c4"},
+		{"https://www.linkedin.com/company/acme/about/", KindCompany, "acme", "https://www.linkedin.com/company/acme"},
+		{"https://www.linkedin.com/showcase/acme-labs", KindCompany, "acme-labs", "https://www.linkedin.com/showcase/acme-labs"},
+		{"https://www.linkedin.com/sales/lead/ACwAAA123,NAME_SEARCH,abc", KindSalesNavigator, "ACwAAA123", "https://www.linkedin.com/sales/lead/ACwAAA123"},
+		{"https://www.linkedin.com/sales/company/1234", KindSalesNavigator, "1234", "https://www.linkedin.com/sales/company/1234"},
+	}
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			parsed, err := Parse(test.raw)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if parsed.Kind != test.kind || parsed.ID != test.id || parsed.Canonical != test.canonical {
+				t.Errorf("Parse() = %+v, want {%s %s %s}", *parsed, test.kind, test.id, test.canonical)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"not a url",
+		"ftp://www.linkedin.com/in/ann",
+		"https://www.linkedin.com.evil.com/in/ann",
+		"https://notlinkedin.com/in/ann",
+		"https://www.linkedin.com/in/",
+		"https://www.linkedin.com/feed/",
+		"https://www.linkedin.com/sales/search/people",
+		"https://www.linkedin.com/sales/lead",
+	} {
+		if parsed, err := Parse(raw); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Parse(%q) = %+v, %v, want ErrInvalidURL", raw, parsed, err)
+		}
+	}
+}
+
+func TestScrapeURL(t *testing.T) {
+	// Every form of a profile URL is scraped from the same URL
+	var scrapeURLs []string
+	for _, raw := range []string{"https://www.linkedin.com/in/ann", "in.linkedin.com/in/Ann/", "https://linkedin.com/in/ann?trk=x"} {
+		parsed, err := Parse(raw)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", raw, err)
+		}
+		scrapeURLs = append(scrapeURLs, parsed.ScrapeURL())
+	}
+	for _, scrapeURL := range scrapeURLs {
+		if scrapeURL != "https://in.linkedin.com/in/ann" {
+			t.Errorf("ScrapeURL() = %q, want https://in.linkedin.com/in/ann", scrapeURL)
+		}
+	}
+}
+
+func TestScrapable(t *testing.T) {
+	profile, _ := Parse("https://www.linkedin.com/in/ann")
+	lead, _ := Parse("https://www.linkedin.com/sales/lead/123")
+	if !profile.Scrapable() || lead.Scrapable() {
+		t.Errorf("Scrapable() = %v for a profile and %v for a Sales Navigator lead", profile.Scrapable(), lead.Scrapable())
+	}
+}
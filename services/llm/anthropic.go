@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// anthropicDefaultMaxTokens is sent when the prompt does not set a limit,
+// the Messages API rejects requests without max_tokens
+const anthropicDefaultMaxTokens = 4096
+
+// anthropicClient translates model configs to the Anthropic Messages API
+type anthropicClient struct {
+	settings ProviderSettings
+	timeout  time.Duration
+}
+
+type anthropicRequest struct {
+	Model       string           `json:"model"`
+	System      string           `json:"system,omitempty"`
+	Messages    []models.Message `json:"messages"`
+	MaxTokens   int              `json:"max_tokens"`
+	Temperature *float32         `json:"temperature,omitempty"`
+	TopP        *float64         `json:"top_p,omitempty"`
+	TopK        float64          `json:"top_k,omitempty"`
+	StopSeqs    []string         `json:"stop_sequences,omitempty"`
+	Stream      bool             `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string         `json:"model"`
+	Usage   anthropicUsage `json:"usage"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string         `json:"model"`
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage `json:"usage"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *anthropicClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, config, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var aiResponse anthropicResponse
+	if err := decodeJSON(resp, &aiResponse); err != nil {
+		return nil, err
+	}
+	var content strings.Builder
+	for _, block := range aiResponse.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	if content.Len() == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return &Completion{
+		Content:  content.String(),
+		Model:    modelName(aiResponse.Model, config.Model),
+		Provider: ProviderAnthropic,
+		Usage:    newTokenUsage(aiResponse.Usage.InputTokens, aiResponse.Usage.OutputTokens),
+	}, nil
+}
+
+func (c *anthropicClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	resp, err := c.send(ctx, config, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion := &Completion{Model: config.Model, Provider: ProviderAnthropic}
+	var content strings.Builder
+	err = scanLines(resp.Body, func(line string) (bool, error) {
+		data, ok := sseData(line)
+		if !ok {
+			return false, nil
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, fmt.Errorf("error parsing stream chunk: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			completion.Model = modelName(event.Message.Model, completion.Model)
+			completion.Usage = newTokenUsage(event.Message.Usage.InputTokens, event.Message.Usage.OutputTokens)
+		case "message_delta":
+			// The final output token count arrives with the message delta
+			completion.Usage = newTokenUsage(completion.Usage.PromptTokens, event.Usage.OutputTokens)
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return false, nil
+			}
+			content.WriteString(event.Delta.Text)
+			return false, onDelta(event.Delta.Text)
+		case "message_stop":
+			return true, nil
+		case "error":
+			return false, fmt.Errorf("model stream failed: %s: %s", event.Error.Type, event.Error.Message)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	completion.Content = content.String()
+	return completion, nil
+}
+
+func (c *anthropicClient) send(ctx context.Context, config models.ModelConfig, stream bool) (*http.Response, error) {
+	if c.settings.Token == "" {
+		return nil, fmt.Errorf("api key not found. Please set the API token")
+	}
+
+	system, messages := splitSystemMessages(config.Messages)
+	request := anthropicRequest{
+		Model:       config.Model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+		TopP:        config.TopP,
+		TopK:        config.TopK,
+		StopSeqs:    config.Stop,
+		Stream:      stream,
+	}
+	if request.MaxTokens == 0 {
+		request.MaxTokens = anthropicDefaultMaxTokens
+	}
+
+	version := os.Getenv("ANTHROPIC_VERSION")
+	if version == "" {
+		version = "2023-06-01"
+	}
+	return postJSON(ctx, c.settings.URI, map[string]string{
+		"x-api-key":         c.settings.Token,
+		"anthropic-version": version,
+	}, request)
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit is open
+var ErrCircuitOpen = errors.New("model endpoint is unavailable, circuit breaker is open")
+
+// BreakerSettings controls when a failing provider stops receiving calls
+type BreakerSettings struct {
+	// FailureThreshold is the number of consecutive failed calls that opens
+	// the circuit, zero disables the breaker
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a trial call is let through
+	Cooldown time.Duration
+}
+
+// breakerClient fails fast while the provider is down. After the cooldown a
+// single trial call is allowed, its outcome closes or reopens the circuit.
+type breakerClient struct {
+	next     Client
+	settings BreakerSettings
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreakerClient(next Client, settings BreakerSettings) Client {
+	return &breakerClient{next: next, settings: settings}
+}
+
+func (c *breakerClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	completion, err := c.next.Complete(ctx, config)
+	c.record(ctx, err)
+	return completion, err
+}
+
+func (c *breakerClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	completion, err := c.next.Stream(ctx, config, onDelta)
+	c.record(ctx, err)
+	return completion, err
+}
+
+func (c *breakerClient) allow() error {
+	if c.settings.FailureThreshold <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures < c.settings.FailureThreshold {
+		return nil
+	}
+	if c.trial || time.Since(c.openedAt) < c.settings.Cooldown {
+		return ErrCircuitOpen
+	}
+	c.trial = true
+	return nil
+}
+
+// record counts only provider failures, a caller cancelling its request or
+// sending an invalid request says nothing about the health of the endpoint
+func (c *breakerClient) record(ctx context.Context, err error) {
+	if c.settings.FailureThreshold <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trial = false
+	if ctx.Err() != nil && err != nil {
+		return
+	}
+	if err == nil || !isRetryable(err) {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.settings.FailureThreshold {
+		c.openedAt = time.Now()
+	}
+}
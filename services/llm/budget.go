@@ -0,0 +1,210 @@
+package llm
+
+import (
+	"aiagent/models"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenPattern splits text roughly the way BPE tokenizers do: runs of letters,
+// runs of digits and single punctuation characters
+var tokenPattern = regexp.MustCompile(`\p{L}+|\p{N}+|[^\s\p{L}\p{N}]`)
+
+// contextWindows lists known context windows by model name fragment, the
+// first matching fragment wins so more specific names come first
+var contextWindows = []struct {
+	fragment string
+	tokens   int
+}{
+	{"llama-3.1", 131072},
+	{"llama-3.2", 131072},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama-3", 8192},
+	{"llama3", 8192},
+	{"claude", 200000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5", 16385},
+	{"mistral", 32768},
+}
+
+// minOutputTokens is always left for the answer when trimming a prompt
+const minOutputTokens = 256
+
+// truncationMarker is appended to every shortened value
+const truncationMarker = " [...]"
+
+// Placeholder is a value substituted into a prompt template
+type Placeholder struct {
+	Key   string
+	Value string
+	// Trimmable values may be shortened to make the prompt fit
+	Trimmable bool
+}
+
+// CountTokens approximates the number of tokens of the text. Words are
+// counted as one token per four characters, which slightly overestimates
+// common tokenizers and keeps the budget on the safe side.
+func CountTokens(text string) int {
+	count := 0
+	for _, token := range tokenPattern.FindAllString(text, -1) {
+		count += (utf8.RuneCountInString(token) + 3) / 4
+	}
+	return count
+}
+
+// TruncateTokens cuts the text after roughly the given number of tokens
+func TruncateTokens(text string, tokens int) string {
+	if tokens <= 0 {
+		return ""
+	}
+	count := 0
+	for _, bounds := range tokenPattern.FindAllStringIndex(text, -1) {
+		count += (utf8.RuneCountInString(text[bounds[0]:bounds[1]]) + 3) / 4
+		if count > tokens {
+			return strings.TrimSpace(text[:bounds[0]]) + truncationMarker
+		}
+	}
+	return text
+}
+
+// ContextWindow returns the context window of the model in tokens. Windows
+// can be configured with MODEL_CONTEXT_WINDOWS="model=tokens,model=tokens",
+// unknown models use MODEL_CONTEXT_WINDOW or 8192.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, entry := range strings.Split(os.Getenv("MODEL_CONTEXT_WINDOWS"), ",") {
+		name, value, found := strings.Cut(entry, "=")
+		tokens, err := strconv.Atoi(strings.TrimSpace(value))
+		if found && err == nil && tokens > 0 && strings.EqualFold(strings.TrimSpace(name), model) {
+			return tokens
+		}
+	}
+	if model != "" {
+		for _, known := range contextWindows {
+			if strings.Contains(model, known.fragment) {
+				return known.tokens
+			}
+		}
+	}
+	return envInt("MODEL_CONTEXT_WINDOW", 8192)
+}
+
+// RenderPrompt replaces the placeholders of the template and sets the result
+// as the user message of the config. When the messages and the requested
+// output do not fit the context window of the model the client sends the
+// config to, the largest trimmable values are shortened first and MaxTokens
+// is lowered to what is left. The keys of the trimmed placeholders are returned.
+func RenderPrompt(client Client, config *models.ModelConfig, template string, placeholders []Placeholder) []string {
+	model := config.Model
+	if resolver, ok := client.(Resolver); ok {
+		model = resolver.Resolve(*config).Model
+	}
+	window := ContextWindow(model)
+	fixedTokens := 0
+	for _, message := range config.Messages {
+		if message.Role != "user" {
+			fixedTokens += CountTokens(message.Content)
+		}
+	}
+	outputTokens := config.MaxTokens
+	if outputTokens <= 0 || outputTokens > window/2 {
+		outputTokens = window / 2
+	}
+	if outputTokens < minOutputTokens {
+		outputTokens = minOutputTokens
+	}
+	budget := window - fixedTokens - outputTokens
+
+	values := make([]Placeholder, len(placeholders))
+	copy(values, placeholders)
+	trimmed := map[string]bool{}
+	prompt := renderTemplate(template, values)
+	for promptTokens := CountTokens(prompt); promptTokens > budget; promptTokens = CountTokens(prompt) {
+		largest, largestTokens := -1, 0
+		for i, value := range values {
+			if !value.Trimmable || !strings.Contains(template, value.Key) {
+				continue
+			}
+			if tokens := CountTokens(value.Value) * strings.Count(template, value.Key); tokens > largestTokens {
+				largest, largestTokens = i, tokens
+			}
+		}
+		if largest < 0 {
+			break
+		}
+
+		occurrences := strings.Count(template, values[largest].Key)
+		excess := promptTokens - budget
+		keep := (largestTokens-excess)/occurrences - CountTokens(truncationMarker)
+		if keep <= 0 {
+			values[largest].Value = ""
+		} else {
+			values[largest].Value = TruncateTokens(values[largest].Value, keep)
+		}
+		// A value is shortened at most twice, the second pass only corrects
+		// the rounding of the token estimate
+		if keep <= 0 || trimmed[values[largest].Key] {
+			values[largest].Trimmable = false
+		}
+		trimmed[values[largest].Key] = true
+		prompt = renderTemplate(template, values)
+	}
+
+	setUserMessage(config, prompt)
+	if remaining := window - fixedTokens - CountTokens(prompt); config.MaxTokens <= 0 || config.MaxTokens > remaining {
+		if remaining < minOutputTokens {
+			remaining = minOutputTokens
+		}
+		config.MaxTokens = remaining
+	}
+
+	keys := make([]string, 0, len(trimmed))
+	for _, placeholder := range placeholders {
+		if trimmed[placeholder.Key] {
+			keys = append(keys, strings.Trim(placeholder.Key, "*"))
+		}
+	}
+	return keys
+}
+
+// fitContextWindow lowers MaxTokens to what the window of the model leaves
+// after the messages, and fails when not even minOutputTokens are left
+func fitContextWindow(config *models.ModelConfig) error {
+	window := ContextWindow(config.Model)
+	promptTokens := 0
+	for _, message := range config.Messages {
+		promptTokens += CountTokens(message.Content)
+	}
+	remaining := window - promptTokens
+	if remaining < minOutputTokens {
+		return fmt.Errorf("prompt of about %d tokens does not fit the %d tokens context window of %s", promptTokens, window, config.Model)
+	}
+	if config.MaxTokens <= 0 || config.MaxTokens > remaining {
+		config.MaxTokens = remaining
+	}
+	return nil
+}
+
+func renderTemplate(template string, placeholders []Placeholder) string {
+	for _, placeholder := range placeholders {
+		template = strings.Replace(template, placeholder.Key, placeholder.Value, -1)
+	}
+	return template
+}
+
+func setUserMessage(config *models.ModelConfig, content string) {
+	for i := len(config.Messages) - 1; i >= 0; i-- {
+		if config.Messages[i].Role == "user" {
+			config.Messages[i].Content = content
+			return
+		}
+	}
+	config.Messages = append(config.Messages, models.Message{Role: "user", Content: content})
+}
@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountAndTruncateTokens(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens int
+	}{
+		{"", 0},
+		{"hello", 2},
+		{"a b c", 3},
+		{"hello, world!", 6},
+		{"2024", 1},
+	}
+	for _, test := range tests {
+		if got := CountTokens(test.text); got != test.tokens {
+			t.Errorf("CountTokens(%q) = %d, want %d", test.text, got, test.tokens)
+		}
+	}
+	if got := TruncateTokens("one two three four", 2); got != "one two"+truncationMarker {
+		t.Errorf("TruncateTokens() = %q", got)
+	}
+	if got := TruncateTokens("short", 10); got != "short" {
+		t.Errorf("TruncateTokens() = %q, want the text unchanged", got)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	t.Setenv("MODEL_CONTEXT_WINDOWS", "my-model=4096")
+	t.Setenv("MODEL_CONTEXT_WINDOW", "")
+	tests := []struct {
+		model  string
+		tokens int
+	}{
+		{"meta-llama/Meta-Llama-3.1-8B-Instruct", 131072},
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"My-Model", 4096},
+		{"unknown", 8192},
+		{"", 8192},
+	}
+	for _, test := range tests {
+		if got := ContextWindow(test.model); got != test.tokens {
+			t.Errorf("ContextWindow(%q) = %d, want %d", test.model, got, test.tokens)
+		}
+	}
+}
+
+// routerWithDefault returns a client whose default model is model
+func routerWithDefault(model string) Client {
+	return NewClient(Settings{
+		Provider:  ProviderMock,
+		Providers: map[string]ProviderSettings{ProviderMock: {Model: model}},
+	})
+}
+
+func TestRenderPromptUsesTheClientDefaultModel(t *testing.T) {
+	research := strings.Repeat("word ", 20000)
+	placeholders := []Placeholder{{Key: "**research**", Value: research, Trimmable: true}}
+
+	config := models.ModelConfig{}
+	truncated := RenderPrompt(routerWithDefault("gpt-4"), &config, "Research: **research**", placeholders)
+	if len(truncated) != 1 || truncated[0] != "research" {
+		t.Fatalf("truncated = %v, want [research]", truncated)
+	}
+	if tokens := CountTokens(config.Messages[0].Content) + config.MaxTokens; tokens > 8192 {
+		t.Errorf("prompt and output take %d tokens, more than the gpt-4 window", tokens)
+	}
+	if config.Model != "" {
+		t.Errorf("Model = %q, RenderPrompt must not pin the default model", config.Model)
+	}
+
+	config = models.ModelConfig{}
+	if truncated := RenderPrompt(routerWithDefault("llama-3.1"), &config, "Research: **research**", placeholders); len(truncated) != 0 {
+		t.Errorf("truncated = %v, the prompt fits the llama-3.1 window", truncated)
+	}
+}
+
+func TestRenderPromptKeepsFixedValues(t *testing.T) {
+	config := models.ModelConfig{Model: "gpt-4", Messages: []models.Message{{Role: "system", Content: "Be brief."}}}
+	RenderPrompt(nil, &config, "Hi **name**, **research**", []Placeholder{
+		{Key: "**name**", Value: "Ann"},
+		{Key: "**research**", Value: strings.Repeat("word ", 20000), Trimmable: true},
+	})
+	prompt := config.Messages[len(config.Messages)-1].Content
+	if !strings.HasPrefix(prompt, "Hi Ann, ") || !strings.HasSuffix(prompt, truncationMarker) {
+		t.Errorf("prompt = %.40q...", prompt)
+	}
+	if config.Messages[0].Content != "Be brief." {
+		t.Errorf("system message changed to %q", config.Messages[0].Content)
+	}
+}
+
+func TestFallbackWithSmallerWindowIsSkipped(t *testing.T) {
+	var primaryCalls, backupCalls int
+	primary := openAIServer(t, http.StatusInternalServerError, &primaryCalls)
+	backup := openAIServer(t, http.StatusOK, &backupCalls)
+	client := NewClient(Settings{
+		Provider:  ProviderOpenAI,
+		Providers: map[string]ProviderSettings{ProviderOpenAI: {URI: primary.URL, Token: "primary-token", Model: "llama-3.1"}},
+		Timeout:   time.Second,
+		Fallbacks: []Fallback{{Provider: ProviderOpenAI, Model: "gpt-4", URI: backup.URL, Token: "backup-token"}},
+	})
+
+	config := models.ModelConfig{}
+	RenderPrompt(client, &config, "**research**", []Placeholder{{Key: "**research**", Value: strings.Repeat("word ", 20000), Trimmable: true}})
+	if _, err := client.Complete(context.Background(), config); err == nil || !strings.Contains(err.Error(), "context window") {
+		t.Errorf("Complete() error = %v, want the fallback skipped", err)
+	}
+	if backupCalls != 0 {
+		t.Errorf("backup calls = %d, want 0", backupCalls)
+	}
+
+	if _, err := client.Complete(context.Background(), models.ModelConfig{Messages: []models.Message{{Role: "user", Content: "hello"}}}); err != nil {
+		t.Errorf("Complete() error = %v, want the answer of the fallback", err)
+	}
+	if backupCalls != 1 {
+		t.Errorf("backup calls = %d, want 1", backupCalls)
+	}
+}
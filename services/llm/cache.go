@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"aiagent/models"
+	"aiagent/repository"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CacheSettings controls the completion cache
+type CacheSettings struct {
+	// Size is the number of completions kept in memory, zero disables the cache
+	Size int
+	TTL  time.Duration
+}
+
+// cachedCompletion is the document stored in the cache collection
+type cachedCompletion struct {
+	Key        string     `bson:"_id"`
+	Completion Completion `bson:"completion"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	ExpiresAt  time.Time  `bson:"expires_at"`
+}
+
+// cacheClient returns stored completions for identical model configs. An in
+// memory LRU sits in front of the Mongo collection, which expires documents
+// through a TTL index on expires_at. Answers of a fallback are not stored, the
+// key is the requested model.
+type cacheClient struct {
+	next     Client
+	repo     repository.Repository
+	settings CacheSettings
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewCacheClient wraps the client with the completion cache, repo may be nil
+// to only cache in memory
+func NewCacheClient(next Client, repo repository.Repository, settings CacheSettings) Client {
+	if settings.Size <= 0 {
+		return next
+	}
+	return &cacheClient{
+		next:     next,
+		repo:     repo,
+		settings: settings,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *cacheClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	if config.BypassCache {
+		return c.next.Complete(ctx, config)
+	}
+	key := cacheKey(config)
+	if completion := c.get(key); completion != nil {
+		return completion, nil
+	}
+	completion, err := c.next.Complete(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, completion)
+	return completion, nil
+}
+
+// Resolve delegates to the wrapped client
+func (c *cacheClient) Resolve(config models.ModelConfig) models.ModelConfig {
+	if resolver, ok := c.next.(Resolver); ok {
+		return resolver.Resolve(config)
+	}
+	return config
+}
+
+// Stream replays a cached completion as a single delta
+func (c *cacheClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	if config.BypassCache {
+		return c.next.Stream(ctx, config, onDelta)
+	}
+	key := cacheKey(config)
+	if completion := c.get(key); completion != nil {
+		return completion, onDelta(completion.Content)
+	}
+	completion, err := c.next.Stream(ctx, config, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, completion)
+	return completion, nil
+}
+
+// cacheKey hashes everything that influences the answer of the model
+func cacheKey(config models.ModelConfig) string {
+	config.Stream = false
+	config.BypassCache = false
+	payload, _ := json.Marshal(struct {
+		Provider string
+		Config   models.ModelConfig
+	}{config.Provider, config})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *cacheClient) get(key string) *Completion {
+	c.mu.Lock()
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*cachedCompletion)
+		if time.Now().Before(entry.ExpiresAt) {
+			c.order.MoveToFront(element)
+			c.mu.Unlock()
+			return cachedCopy(entry.Completion)
+		}
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	if c.repo == nil {
+		return nil
+	}
+	var entry cachedCompletion
+	// The TTL monitor runs only once a minute, so expiry is checked here as well
+	err := c.repo.FindOne(bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&entry)
+	if err != nil {
+		return nil
+	}
+	c.remember(&entry)
+	return cachedCopy(entry.Completion)
+}
+
+func (c *cacheClient) put(key string, completion *Completion) {
+	if completion.Fallback {
+		return
+	}
+	entry := &cachedCompletion{
+		Key:        key,
+		Completion: *completion,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(c.settings.TTL),
+	}
+	entry.Completion.Cached = false
+	c.remember(entry)
+
+	if c.repo == nil {
+		return
+	}
+	err := c.repo.UpdateOne(bson.M{"_id": key}, bson.M{"$set": entry}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Warn("Error storing completion in cache: ", err)
+	}
+}
+
+// remember stores the entry in the in memory LRU, evicting the least recently used one
+func (c *cacheClient) remember(entry *cachedCompletion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, exists := c.entries[entry.Key]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.settings.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedCompletion).Key)
+	}
+}
+
+func cachedCopy(completion Completion) *Completion {
+	completion.Cached = true
+	return &completion
+}
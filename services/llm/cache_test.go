@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		primary     int
+		withBackup  bool
+		wantCached  bool
+		wantPrimary int
+		wantBackup  int
+	}{
+		{"primary answer is cached", http.StatusOK, false, true, 1, 0},
+		{"fallback answer is not cached", http.StatusInternalServerError, true, false, 2, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var primaryCalls, backupCalls int
+			primary := openAIServer(t, test.primary, &primaryCalls)
+			settings := Settings{
+				Provider:  ProviderOpenAI,
+				Providers: map[string]ProviderSettings{ProviderOpenAI: {URI: primary.URL, Token: "primary-token", Model: "primary-model"}},
+				Timeout:   time.Second,
+				Breaker:   BreakerSettings{FailureThreshold: 10, Cooldown: time.Hour},
+			}
+			if test.withBackup {
+				backup := openAIServer(t, http.StatusOK, &backupCalls)
+				settings.Fallbacks = []Fallback{{Provider: ProviderOpenAI, Model: "backup-model", URI: backup.URL, Token: "backup-token"}}
+			}
+			client := NewCacheClient(NewClient(settings), nil, CacheSettings{Size: 10, TTL: time.Hour})
+			config := models.ModelConfig{Messages: []models.Message{{Role: "user", Content: "hello"}}}
+
+			var completion *Completion
+			for i := 0; i < 2; i++ {
+				var err error
+				if completion, err = client.Complete(context.Background(), config); err != nil {
+					t.Fatalf("call %d: Complete() error = %v", i+1, err)
+				}
+			}
+			if completion.Cached != test.wantCached {
+				t.Errorf("second call cached = %v, want %v", completion.Cached, test.wantCached)
+			}
+			if primaryCalls != test.wantPrimary || backupCalls != test.wantBackup {
+				t.Errorf("primary calls = %d, backup calls = %d, want %d and %d", primaryCalls, backupCalls, test.wantPrimary, test.wantBackup)
+			}
+		})
+	}
+}
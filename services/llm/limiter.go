@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// LimitSettings bounds the traffic sent to a provider, zero disables a limit
+type LimitSettings struct {
+	MaxConcurrent     int
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// limitClient queues calls until a concurrency slot and enough request and
+// token budget are available. It is shared by every caller of a provider so
+// concurrent uploads and generate requests cannot exceed its limits together.
+type limitClient struct {
+	next     Client
+	slots    chan struct{}
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+func newLimitClient(next Client, settings LimitSettings) Client {
+	client := &limitClient{
+		next:     next,
+		requests: newTokenBucket(settings.RequestsPerMinute),
+		tokens:   newTokenBucket(settings.TokensPerMinute),
+	}
+	if settings.MaxConcurrent > 0 {
+		client.slots = make(chan struct{}, settings.MaxConcurrent)
+	}
+	return client
+}
+
+func (c *limitClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	release, err := c.acquire(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.next.Complete(ctx, config)
+}
+
+func (c *limitClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	release, err := c.acquire(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.next.Stream(ctx, config, onDelta)
+}
+
+// acquire waits for the rate limits and a concurrency slot, the returned
+// function gives the slot back
+func (c *limitClient) acquire(ctx context.Context, config models.ModelConfig) (func(), error) {
+	if err := c.requests.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	// Providers count the requested output against the token limit as well
+	tokens := config.MaxTokens
+	for _, message := range config.Messages {
+		tokens += CountTokens(message.Content)
+	}
+	if err := c.tokens.wait(ctx, tokens); err != nil {
+		return nil, err
+	}
+
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tokenBucket refills perMinute units evenly over a minute
+type tokenBucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	available float64
+	updatedAt time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{capacity: float64(perMinute), available: float64(perMinute), updatedAt: time.Now()}
+}
+
+// wait blocks until n units are available and takes them. A request larger
+// than the whole bucket is let through once the bucket is full.
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	if b == nil {
+		return nil
+	}
+	needed := math.Min(float64(n), b.capacity)
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.available = math.Min(b.capacity, b.available+now.Sub(b.updatedAt).Minutes()*b.capacity)
+		b.updatedAt = now
+		if b.available >= needed {
+			b.available -= needed
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((needed - b.available) / b.capacity * float64(time.Minute))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
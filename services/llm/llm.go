@@ -0,0 +1,383 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported model providers
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+	// ProviderMock answers locally, see mockClient
+	ProviderMock = "mock"
+)
+
+// defaultProviders are used when no endpoint or model is configured for a provider
+var defaultProviders = map[string]ProviderSettings{
+	ProviderOpenAI:    {URI: "https://api.openai.com/v1/chat/completions", Model: "meta-llama/Meta-Llama-3.1-8B-Instruct"},
+	ProviderAnthropic: {URI: "https://api.anthropic.com/v1/messages", Model: "claude-3-5-haiku-latest"},
+	ProviderOllama:    {URI: "http://localhost:11434/api/chat", Model: "llama3.1"},
+	ProviderMock:      {Model: "mock-model"},
+}
+
+// Client is the single entry point for every call made to a language model.
+// Controllers depend on this interface so the provider can be swapped in tests.
+type Client interface {
+	// Complete sends the model config and waits for the full answer.
+	Complete(ctx context.Context, config models.ModelConfig) (*Completion, error)
+	// Stream sends the model config with streaming enabled and invokes onDelta
+	// for every piece of content received. The assembled answer is returned
+	// once the upstream stream ends.
+	Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error)
+}
+
+// Resolver is implemented by clients that know the provider and model a
+// config without them is sent to
+type Resolver interface {
+	// Resolve fills in the provider and model defaults of the config
+	Resolve(config models.ModelConfig) models.ModelConfig
+}
+
+// Completion is the normalized answer returned by every provider
+type Completion struct {
+	Content  string            `json:"content"`
+	Model    string            `json:"model"`
+	Provider string            `json:"provider"`
+	Usage    models.TokenUsage `json:"usage"`
+	// Cached is set when the completion was served from the cache
+	Cached bool `json:"cached" bson:"-"`
+	// Fallback is set when a fallback answered instead of the requested model
+	Fallback bool `json:"fallback,omitempty" bson:"-"`
+}
+
+// APIError is returned when the model endpoint answers with a non 200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the provider, zero when not sent
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("model endpoint failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrEmptyResponse is returned when the model answered without any content
+var ErrEmptyResponse = fmt.Errorf("no response content found")
+
+// ProviderSettings holds the endpoint and credentials of a single provider
+type ProviderSettings struct {
+	URI   string
+	Token string
+	// Model is used when the model config does not name one
+	Model  string
+	Limits LimitSettings
+}
+
+// Settings holds the connection details shared by all providers
+type Settings struct {
+	// Provider is used whenever a model config does not name one
+	Provider  string
+	Providers map[string]ProviderSettings
+	Timeout   time.Duration
+	Retry     RetrySettings
+	Breaker   BreakerSettings
+	// Fallbacks are tried in order when the requested model fails
+	Fallbacks []Fallback
+	Cache     CacheSettings
+}
+
+// Fallback names a provider and optionally a model to try when a call fails
+type Fallback struct {
+	Provider string
+	Model    string
+	// URI points the fallback at its own endpoint, with its own circuit
+	// breaker and limits. The endpoint of the provider is used when empty.
+	URI    string
+	Token  string
+	Limits LimitSettings
+}
+
+// SettingsFromEnv reads the model endpoint configuration from the environment.
+// MODELURI, TOKEN and MODEL configure the default provider selected by MODEL_PROVIDER,
+// every provider can also be configured with <PROVIDER>_MODELURI, <PROVIDER>_TOKEN
+// and <PROVIDER>_MODEL, and limited with <PROVIDER>_MAX_CONCURRENT,
+// <PROVIDER>_REQUESTS_PER_MINUTE and <PROVIDER>_TOKENS_PER_MINUTE.
+// The Nth entry of MODEL_FALLBACKS gets its own endpoint with
+// MODEL_FALLBACK_<N>_MODELURI and MODEL_FALLBACK_<N>_TOKEN, limited the same way.
+func SettingsFromEnv() Settings {
+	timeout := time.Duration(envInt("MODEL_TIMEOUT_SECONDS", 60)) * time.Second
+
+	defaultProvider := strings.ToLower(os.Getenv("MODEL_PROVIDER"))
+	if defaultProvider == "" {
+		defaultProvider = ProviderOpenAI
+	}
+
+	providers := make(map[string]ProviderSettings)
+	for name, defaults := range defaultProviders {
+		prefix := strings.ToUpper(name) + "_"
+		provider := ProviderSettings{
+			URI:    os.Getenv(prefix + "MODELURI"),
+			Token:  os.Getenv(prefix + "TOKEN"),
+			Model:  os.Getenv(prefix + "MODEL"),
+			Limits: limitsFromEnv(prefix),
+		}
+		if name == defaultProvider {
+			provider.URI = firstNonEmpty(provider.URI, os.Getenv("MODELURI"))
+			provider.Token = firstNonEmpty(provider.Token, os.Getenv("TOKEN"))
+			provider.Model = firstNonEmpty(provider.Model, os.Getenv("MODEL"))
+		}
+		provider.URI = firstNonEmpty(provider.URI, defaults.URI)
+		provider.Model = firstNonEmpty(provider.Model, defaults.Model)
+		providers[name] = provider
+	}
+
+	return Settings{
+		Provider:  defaultProvider,
+		Providers: providers,
+		Timeout:   timeout,
+		Retry: RetrySettings{
+			MaxRetries: envInt("MODEL_MAX_RETRIES", 3),
+			BaseDelay:  time.Duration(envInt("MODEL_RETRY_BASE_DELAY_MS", 500)) * time.Millisecond,
+			MaxDelay:   time.Duration(envInt("MODEL_RETRY_MAX_DELAY_MS", 10000)) * time.Millisecond,
+		},
+		Breaker: BreakerSettings{
+			FailureThreshold: envInt("MODEL_BREAKER_THRESHOLD", 5),
+			Cooldown:         time.Duration(envInt("MODEL_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second,
+		},
+		Fallbacks: fallbacksFromEnv(),
+		Cache: CacheSettings{
+			Size: envInt("MODEL_CACHE_SIZE", 1000),
+			TTL:  time.Duration(envInt("MODEL_CACHE_TTL_HOURS", 168)) * time.Hour,
+		},
+	}
+}
+
+// NewClient builds a client that dispatches every call to the provider named
+// in the model config, or to the default provider of the settings.
+// Every provider and every fallback with its own endpoint gets its own
+// circuit breaker and limits, and retries failed calls.
+func NewClient(settings Settings) Client {
+	providers := make(map[string]Client, len(settings.Providers))
+	for name := range defaultProviders {
+		providers[name] = newProviderClient(name, settings.Providers[name], settings)
+	}
+	fallbacks := make(map[int]Client)
+	for i, fallback := range settings.Fallbacks {
+		if fallback.URI == "" {
+			continue
+		}
+		provider := ProviderSettings{URI: fallback.URI, Token: fallback.Token, Model: fallback.Model, Limits: fallback.Limits}
+		provider.Model = firstNonEmpty(provider.Model, settings.Providers[fallback.Provider].Model)
+		fallbacks[i] = newProviderClient(fallback.Provider, provider, settings)
+	}
+	return &providerRouter{
+		settings:  settings,
+		clients:   providers,
+		fallbacks: fallbacks,
+	}
+}
+
+// newProviderClient returns the client of a single endpoint, nil for unknown providers
+func newProviderClient(name string, provider ProviderSettings, settings Settings) Client {
+	var client Client
+	switch name {
+	case ProviderOpenAI:
+		client = &openAIClient{settings: provider, timeout: settings.Timeout}
+	case ProviderAnthropic:
+		client = &anthropicClient{settings: provider, timeout: settings.Timeout}
+	case ProviderOllama:
+		client = &ollamaClient{settings: provider, timeout: settings.Timeout}
+	case ProviderMock:
+		client = newMockClient()
+	default:
+		return nil
+	}
+	// Every attempt waits for the limits, a retry does not hold a slot while backing off
+	limited := newLimitClient(client, provider.Limits)
+	return newBreakerClient(newRetryClient(limited, settings.Retry), settings.Breaker)
+}
+
+// providerRouter picks the provider client for every request
+type providerRouter struct {
+	settings Settings
+	clients  map[string]Client
+	// fallbacks holds the clients of the fallbacks with their own endpoint by index
+	fallbacks map[int]Client
+}
+
+// Complete tries the requested model first and then every fallback in order
+func (r *providerRouter) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	var lastErr error
+	for i, candidate := range r.candidates(config) {
+		client, candidate, err := r.resolve(i, candidate)
+		if err != nil {
+			log.Warnf("Skipping model %s/%s: %v", candidate.Provider, candidate.Model, err)
+			// The error of a model that was called says more than a missing provider
+			if lastErr == nil {
+				lastErr = err
+			}
+			continue
+		}
+		// The prompt was fitted to the requested model only
+		if i > 0 {
+			if err := fitContextWindow(&candidate); err != nil {
+				log.Warnf("Skipping fallback %s/%s: %v", candidate.Provider, candidate.Model, err)
+				lastErr = err
+				continue
+			}
+		}
+		completion, err := client.Complete(ctx, candidate)
+		if err == nil {
+			ensureUsage(candidate, completion)
+			completion.Fallback = i > 0
+			return completion, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Warnf("Model %s/%s failed: %v", candidate.Provider, candidate.Model, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Stream falls back like Complete as long as no content was sent to the caller
+func (r *providerRouter) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	var lastErr error
+	for i, candidate := range r.candidates(config) {
+		client, candidate, err := r.resolve(i, candidate)
+		if err != nil {
+			log.Warnf("Skipping model %s/%s: %v", candidate.Provider, candidate.Model, err)
+			// The error of a model that was called says more than a missing provider
+			if lastErr == nil {
+				lastErr = err
+			}
+			continue
+		}
+		// The prompt was fitted to the requested model only
+		if i > 0 {
+			if err := fitContextWindow(&candidate); err != nil {
+				log.Warnf("Skipping fallback %s/%s: %v", candidate.Provider, candidate.Model, err)
+				lastErr = err
+				continue
+			}
+		}
+		started := false
+		completion, err := client.Stream(ctx, candidate, func(delta string) error {
+			started = true
+			return onDelta(delta)
+		})
+		if err == nil {
+			ensureUsage(candidate, completion)
+			completion.Fallback = i > 0
+			return completion, nil
+		}
+		if started || ctx.Err() != nil {
+			return nil, err
+		}
+		log.Warnf("Model %s/%s failed: %v", candidate.Provider, candidate.Model, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// candidates returns the requested config followed by one config per fallback
+func (r *providerRouter) candidates(config models.ModelConfig) []models.ModelConfig {
+	candidates := []models.ModelConfig{config}
+	for _, fallback := range r.settings.Fallbacks {
+		candidate := config
+		candidate.Provider = fallback.Provider
+		candidate.Model = fallback.Model
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// resolve returns the client of the candidate at the index, 0 being the
+// requested config and i the fallback i-1, and fills in the provider and
+// model defaults
+func (r *providerRouter) resolve(index int, config models.ModelConfig) (Client, models.ModelConfig, error) {
+	config.Provider = strings.ToLower(firstNonEmpty(config.Provider, r.settings.Provider))
+	client, exists := r.fallbacks[index-1]
+	if !exists {
+		client, exists = r.clients[config.Provider]
+	}
+	if !exists || client == nil {
+		return nil, config, fmt.Errorf("unsupported model provider %q", config.Provider)
+	}
+	config.Model = firstNonEmpty(config.Model, r.settings.Providers[config.Provider].Model)
+	return client, config, nil
+}
+
+// Resolve fills in the provider and model the requested config is sent to
+func (r *providerRouter) Resolve(config models.ModelConfig) models.ModelConfig {
+	_, config, _ = r.resolve(0, config)
+	return config
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// envInt reads a positive integer from the environment
+func envInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// limitsFromEnv reads <prefix>MAX_CONCURRENT (8), <prefix>REQUESTS_PER_MINUTE
+// and <prefix>TOKENS_PER_MINUTE
+func limitsFromEnv(prefix string) LimitSettings {
+	return LimitSettings{
+		MaxConcurrent:     envInt(prefix+"MAX_CONCURRENT", 8),
+		RequestsPerMinute: envInt(prefix+"REQUESTS_PER_MINUTE", 0),
+		TokensPerMinute:   envInt(prefix+"TOKENS_PER_MINUTE", 0),
+	}
+}
+
+// fallbacksFromEnv reads MODEL_FALLBACKS and the endpoint of every fallback
+func fallbacksFromEnv() []Fallback {
+	fallbacks := parseFallbacks(os.Getenv("MODEL_FALLBACKS"))
+	for i := range fallbacks {
+		prefix := "MODEL_FALLBACK_" + strconv.Itoa(i+1) + "_"
+		fallbacks[i].URI = os.Getenv(prefix + "MODELURI")
+		fallbacks[i].Token = os.Getenv(prefix + "TOKEN")
+		fallbacks[i].Limits = limitsFromEnv(prefix)
+	}
+	return fallbacks
+}
+
+// parseFallbacks reads a comma separated list of provider or provider:model
+// entries, e.g. "anthropic:claude-3-5-haiku-latest,ollama"
+func parseFallbacks(value string) []Fallback {
+	var fallbacks []Fallback
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(entry, ":")
+		fallbacks = append(fallbacks, Fallback{
+			Provider: strings.ToLower(strings.TrimSpace(provider)),
+			Model:    strings.TrimSpace(model),
+		})
+	}
+	return fallbacks
+}
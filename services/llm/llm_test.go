@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// openAIServer answers every request with the status, and with the model
+// name as content when the status is 200
+func openAIServer(t *testing.T, status int, calls *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"model":"%s","choices":[{"message":{"content":"from %s"}}]}`, r.Host, r.Host)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFallbackWithOwnEndpoint(t *testing.T) {
+	var primaryCalls, backupCalls int
+	primary := openAIServer(t, http.StatusInternalServerError, &primaryCalls)
+	backup := openAIServer(t, http.StatusOK, &backupCalls)
+
+	settings := Settings{
+		Provider:  ProviderOpenAI,
+		Providers: map[string]ProviderSettings{ProviderOpenAI: {URI: primary.URL, Token: "primary-token", Model: "primary-model"}},
+		Timeout:   time.Second,
+		Breaker:   BreakerSettings{FailureThreshold: 1, Cooldown: time.Hour},
+		Fallbacks: []Fallback{{Provider: ProviderOpenAI, Model: "backup-model", URI: backup.URL, Token: "backup-token"}},
+	}
+	client := NewClient(settings)
+	config := models.ModelConfig{Messages: []models.Message{{Role: "user", Content: "hello"}}}
+
+	for i := 0; i < 2; i++ {
+		completion, err := client.Complete(context.Background(), config)
+		if err != nil {
+			t.Fatalf("call %d: Complete() error = %v", i+1, err)
+		}
+		if want := "from " + backup.Listener.Addr().String(); completion.Content != want {
+			t.Errorf("call %d: content = %q, want %q", i+1, completion.Content, want)
+		}
+	}
+	// The open circuit of the primary endpoint does not stop the backup
+	if primaryCalls != 1 || backupCalls != 2 {
+		t.Errorf("primary calls = %d, backup calls = %d, want 1 and 2", primaryCalls, backupCalls)
+	}
+}
+
+func TestFallbacksFromEnv(t *testing.T) {
+	t.Setenv("MODEL_FALLBACKS", "openai:backup-model, anthropic")
+	t.Setenv("MODEL_FALLBACK_1_MODELURI", "https://backup.example.com/v1/chat/completions")
+	t.Setenv("MODEL_FALLBACK_1_TOKEN", "secret")
+	t.Setenv("MODEL_FALLBACK_1_REQUESTS_PER_MINUTE", "60")
+
+	fallbacks := fallbacksFromEnv()
+	if len(fallbacks) != 2 {
+		t.Fatalf("got %d fallbacks, want 2", len(fallbacks))
+	}
+	want := Fallback{
+		Provider: ProviderOpenAI,
+		Model:    "backup-model",
+		URI:      "https://backup.example.com/v1/chat/completions",
+		Token:    "secret",
+		Limits:   LimitSettings{MaxConcurrent: 8, RequestsPerMinute: 60},
+	}
+	if fallbacks[0] != want {
+		t.Errorf("fallbacks[0] = %+v, want %+v", fallbacks[0], want)
+	}
+	if fallbacks[1].Provider != ProviderAnthropic || fallbacks[1].Model != "" || fallbacks[1].URI != "" {
+		t.Errorf("fallbacks[1] = %+v, want the anthropic provider endpoint", fallbacks[1])
+	}
+}
+
+func TestUnresolvedFallbackIsSkipped(t *testing.T) {
+	tests := []struct {
+		name       string
+		fallbacks  []string
+		wantBackup bool
+		wantStatus int
+	}{
+		{"next fallback answers", []string{"unknown", ProviderOpenAI}, true, 0},
+		{"primary error is kept", []string{"unknown"}, false, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var primaryCalls, backupCalls int
+			primary := openAIServer(t, http.StatusInternalServerError, &primaryCalls)
+			backup := openAIServer(t, http.StatusOK, &backupCalls)
+			settings := Settings{
+				Provider:  ProviderOpenAI,
+				Providers: map[string]ProviderSettings{ProviderOpenAI: {URI: primary.URL, Token: "primary-token", Model: "primary-model"}},
+				Timeout:   time.Second,
+				Breaker:   BreakerSettings{FailureThreshold: 10, Cooldown: time.Hour},
+			}
+			for _, provider := range test.fallbacks {
+				settings.Fallbacks = append(settings.Fallbacks, Fallback{Provider: provider, Model: "backup-model", URI: backup.URL, Token: "backup-token"})
+			}
+			config := models.ModelConfig{Messages: []models.Message{{Role: "user", Content: "hello"}}}
+
+			completions := map[string]func() (*Completion, error){
+				"Complete": func() (*Completion, error) { return NewClient(settings).Complete(context.Background(), config) },
+				"Stream": func() (*Completion, error) {
+					return NewClient(settings).Stream(context.Background(), config, func(string) error { return nil })
+				},
+			}
+			for method, complete := range completions {
+				completion, err := complete()
+				if test.wantBackup {
+					if err != nil || !completion.Fallback {
+						t.Errorf("%s() = %v, %v, want the answer of the next fallback", method, completion, err)
+					}
+					continue
+				}
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != test.wantStatus {
+					t.Errorf("%s() error = %v, want the %d of the primary", method, err, test.wantStatus)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MockRule scripts the answer of the mock provider for prompts containing Match
+type MockRule struct {
+	// Match is searched in the user and system messages, empty matches every prompt
+	Match   string `json:"match"`
+	Content string `json:"content"`
+	// Status makes the call fail with this HTTP status
+	Status int `json:"status"`
+	// FailTimes limits Status to the first calls, later calls return Content
+	FailTimes int `json:"fail_times"`
+	// RetryAfterSeconds is returned with a failing Status
+	RetryAfterSeconds int `json:"retry_after_seconds"`
+	DelayMs           int `json:"delay_ms"`
+	ChunkDelayMs      int `json:"chunk_delay_ms"`
+}
+
+// mockClient is a provider for local development and tests that never leaves
+// the process. Without a script it answers deterministically: JSON requests get
+// the example object of their schema and other requests a summary of the prompt.
+// MOCK_MODEL_SCRIPT can point to a JSON file with a list of MockRule.
+type mockClient struct {
+	rules []MockRule
+
+	mu    sync.Mutex
+	calls map[int]int
+}
+
+func newMockClient() *mockClient {
+	client := &mockClient{calls: map[int]int{}}
+	path := os.Getenv("MOCK_MODEL_SCRIPT")
+	if path == "" {
+		return client
+	}
+	script, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(script, &client.rules)
+	}
+	if err != nil {
+		log.Warn("Error loading the mock model script, using the default answers: ", err)
+	}
+	return client
+}
+
+func (c *mockClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	content, _, err := c.answer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Completion{Content: content, Model: config.Model, Provider: ProviderMock}, nil
+}
+
+// Stream sends the answer word by word
+func (c *mockClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	content, rule, err := c.answer(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(content, " ") {
+		if err := sleep(ctx, time.Duration(rule.ChunkDelayMs)*time.Millisecond); err != nil {
+			return nil, err
+		}
+		if err := onDelta(word); err != nil {
+			return nil, err
+		}
+	}
+	return &Completion{Content: content, Model: config.Model, Provider: ProviderMock}, nil
+}
+
+// answer finds the rule for the prompt and applies its delay and failures
+func (c *mockClient) answer(ctx context.Context, config models.ModelConfig) (string, MockRule, error) {
+	var prompt strings.Builder
+	for _, message := range config.Messages {
+		prompt.WriteString(message.Content)
+		prompt.WriteString("\n")
+	}
+
+	for index, rule := range c.rules {
+		if !strings.Contains(prompt.String(), rule.Match) {
+			continue
+		}
+		if err := sleep(ctx, time.Duration(rule.DelayMs)*time.Millisecond); err != nil {
+			return "", rule, err
+		}
+		if rule.Status != 0 && rule.Status != 200 {
+			c.mu.Lock()
+			c.calls[index]++
+			failing := rule.FailTimes <= 0 || c.calls[index] <= rule.FailTimes
+			c.mu.Unlock()
+			if failing {
+				return "", rule, &APIError{
+					StatusCode: rule.Status,
+					Body:       "mock failure",
+					RetryAfter: time.Duration(rule.RetryAfterSeconds) * time.Second,
+				}
+			}
+		}
+		if rule.Content != "" {
+			return rule.Content, rule, nil
+		}
+		return defaultMockAnswer(config), rule, nil
+	}
+	return defaultMockAnswer(config), MockRule{}, nil
+}
+
+func defaultMockAnswer(config models.ModelConfig) string {
+	var system, user string
+	for _, message := range config.Messages {
+		switch message.Role {
+		case "system":
+			system = message.Content
+		case "user":
+			user = message.Content
+		}
+	}
+
+	if config.ResponseFormat != nil {
+		// The schema example of a structured request is itself a valid answer
+		start := strings.LastIndex(system, "\n{")
+		end := strings.LastIndex(system, "}")
+		if start >= 0 && end > start && json.Valid([]byte(system[start+1:end+1])) {
+			return system[start+1 : end+1]
+		}
+		return "{}"
+	}
+
+	summary := strings.Join(strings.Fields(user), " ")
+	if len(summary) > 200 {
+		summary = summary[:200]
+	}
+	return fmt.Sprintf("Mock answer from %s to a prompt of %d tokens: %s", config.Model, CountTokens(user), summary)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
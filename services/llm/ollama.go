@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ollamaClient translates model configs to the Ollama chat API
+type ollamaClient struct {
+	settings ProviderSettings
+	timeout  time.Duration
+}
+
+type ollamaRequest struct {
+	Model    string           `json:"model"`
+	Messages []models.Message `json:"messages"`
+	Stream   bool             `json:"stream"`
+	Format   string           `json:"format,omitempty"`
+	Options  ollamaOptions    `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	TopK        float64  `json:"top_k,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// ollamaResponse is both the full answer and a single line of a stream
+type ollamaResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+func (c *ollamaClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, config, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var aiResponse ollamaResponse
+	if err := decodeJSON(resp, &aiResponse); err != nil {
+		return nil, err
+	}
+	if aiResponse.Error != "" {
+		return nil, fmt.Errorf("model request failed: %s", aiResponse.Error)
+	}
+	if aiResponse.Message.Content == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	return &Completion{
+		Content:  aiResponse.Message.Content,
+		Model:    modelName(aiResponse.Model, config.Model),
+		Provider: ProviderOllama,
+		Usage:    newTokenUsage(aiResponse.PromptEvalCount, aiResponse.EvalCount),
+	}, nil
+}
+
+func (c *ollamaClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	resp, err := c.send(ctx, config, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion := &Completion{Model: config.Model, Provider: ProviderOllama}
+	var content strings.Builder
+	// Ollama streams newline delimited JSON objects instead of Server-Sent Events
+	err = scanLines(resp.Body, func(line string) (bool, error) {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return false, fmt.Errorf("error parsing stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return false, fmt.Errorf("model stream failed: %s", chunk.Error)
+		}
+		completion.Model = modelName(chunk.Model, completion.Model)
+		if chunk.Done {
+			completion.Usage = newTokenUsage(chunk.PromptEvalCount, chunk.EvalCount)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return false, err
+			}
+		}
+		return chunk.Done, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	completion.Content = content.String()
+	return completion, nil
+}
+
+func (c *ollamaClient) send(ctx context.Context, config models.ModelConfig, stream bool) (*http.Response, error) {
+	request := ollamaRequest{
+		Model:    config.Model,
+		Messages: config.Messages,
+		Stream:   stream,
+		Options: ollamaOptions{
+			Temperature: config.Temperature,
+			TopP:        config.TopP,
+			TopK:        config.TopK,
+			NumPredict:  config.MaxTokens,
+			Stop:        config.Stop,
+		},
+	}
+
+	if config.ResponseFormat != nil {
+		request.Format = "json"
+	}
+
+	// A local Ollama server does not need credentials, but a proxy in front of it might
+	headers := map[string]string{}
+	if c.settings.Token != "" {
+		headers["Authorization"] = "Bearer " + c.settings.Token
+	}
+	return postJSON(ctx, c.settings.URI, headers, request)
+}
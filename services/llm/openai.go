@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// openAIClient talks to an OpenAI compatible chat completions endpoint,
+// models.ModelConfig already matches its request shape
+type openAIClient struct {
+	settings ProviderSettings
+	timeout  time.Duration
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) tokenUsage() models.TokenUsage {
+	if u == nil {
+		return models.TokenUsage{}
+	}
+	return models.TokenUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+type openAIResponse struct {
+	Model   string       `json:"model"`
+	Usage   *openAIUsage `json:"usage"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type openAIStreamChunk struct {
+	Model   string       `json:"model"`
+	Usage   *openAIUsage `json:"usage"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (c *openAIClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	config.Stream = false
+	resp, err := c.send(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var aiResponse openAIResponse
+	if err := decodeJSON(resp, &aiResponse); err != nil {
+		return nil, err
+	}
+	if len(aiResponse.Choices) == 0 || aiResponse.Choices[0].Message.Content == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	return &Completion{
+		Content:  aiResponse.Choices[0].Message.Content,
+		Model:    modelName(aiResponse.Model, config.Model),
+		Provider: ProviderOpenAI,
+		Usage:    aiResponse.Usage.tokenUsage(),
+	}, nil
+}
+
+func (c *openAIClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	config.Stream = true
+	resp, err := c.send(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion := &Completion{Model: config.Model, Provider: ProviderOpenAI}
+	var content strings.Builder
+	err = scanLines(resp.Body, func(line string) (bool, error) {
+		data, ok := sseData(line)
+		if !ok {
+			return false, nil
+		}
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("error parsing stream chunk: %w", err)
+		}
+		completion.Model = modelName(chunk.Model, completion.Model)
+		if chunk.Usage != nil {
+			completion.Usage = chunk.Usage.tokenUsage()
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return false, nil
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		return false, onDelta(chunk.Choices[0].Delta.Content)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	completion.Content = content.String()
+	return completion, nil
+}
+
+func (c *openAIClient) send(ctx context.Context, config models.ModelConfig) (*http.Response, error) {
+	if c.settings.Token == "" {
+		return nil, fmt.Errorf("bearer token not found. Please set the API token")
+	}
+	return postJSON(ctx, c.settings.URI, map[string]string{"Authorization": "Bearer " + c.settings.Token}, config)
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"aiagent/models"
+	"encoding/json"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Price is the cost of a model in currency units per million tokens
+type Price struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// Prices maps model names to their price
+type Prices map[string]Price
+
+// PricesFromEnv reads the price table from MODEL_PRICES, a JSON object such as
+// {"meta-llama/Meta-Llama-3.1-8B-Instruct":{"input_per_million":0.1,"output_per_million":0.2}}.
+// The "default" entry prices models that are not listed.
+func PricesFromEnv() Prices {
+	prices := Prices{}
+	value := os.Getenv("MODEL_PRICES")
+	if value == "" {
+		return prices
+	}
+	if err := json.Unmarshal([]byte(value), &prices); err != nil {
+		log.Warn("Error parsing MODEL_PRICES, costs will be reported as zero: ", err)
+		return Prices{}
+	}
+	return prices
+}
+
+// Cost returns the price of the token usage for the model
+func (p Prices) Cost(model string, usage models.TokenUsage) float64 {
+	price, exists := p[model]
+	if !exists {
+		for name, candidate := range p {
+			if strings.EqualFold(name, model) {
+				price, exists = candidate, true
+				break
+			}
+		}
+	}
+	if !exists {
+		price = p["default"]
+	}
+	return (float64(usage.PromptTokens)*price.InputPerMillion + float64(usage.CompletionTokens)*price.OutputPerMillion) / 1e6
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RetrySettings controls how failed model calls are retried
+type RetrySettings struct {
+	// MaxRetries is the number of attempts made after the first one
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// retryClient retries rate limited, failing and timed out calls with
+// exponential backoff and full jitter
+type retryClient struct {
+	next     Client
+	settings RetrySettings
+}
+
+func newRetryClient(next Client, settings RetrySettings) Client {
+	return &retryClient{next: next, settings: settings}
+}
+
+func (c *retryClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	var completion *Completion
+	err := c.do(ctx, func() (bool, error) {
+		var err error
+		completion, err = c.next.Complete(ctx, config)
+		return true, err
+	})
+	return completion, err
+}
+
+func (c *retryClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	var completion *Completion
+	err := c.do(ctx, func() (bool, error) {
+		// Once content reached the caller the stream cannot be replayed
+		started := false
+		var err error
+		completion, err = c.next.Stream(ctx, config, func(delta string) error {
+			started = true
+			return onDelta(delta)
+		})
+		return !started, err
+	})
+	return completion, err
+}
+
+// do runs the attempt until it succeeds, fails permanently or the retries are exhausted
+func (c *retryClient) do(ctx context.Context, attempt func() (bool, error)) error {
+	for retry := 0; ; retry++ {
+		retryable, err := attempt()
+		if err == nil {
+			return nil
+		}
+		if !retryable || retry >= c.settings.MaxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		delay, wait := c.backoff(retry, err)
+		if !wait {
+			log.Warnf("Model call failed and asked to retry after more than %s, giving up: %v", c.settings.MaxDelay, err)
+			return err
+		}
+		log.Warnf("Model call failed, retrying in %s (attempt %d of %d): %v", delay, retry+1, c.settings.MaxRetries, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff honours Retry-After and otherwise waits a random time up to the
+// exponential delay. It returns false when Retry-After exceeds MaxDelay, the
+// call then fails so the next fallback is tried instead of holding the caller.
+func (c *retryClient) backoff(retry int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if c.settings.MaxDelay > 0 && apiErr.RetryAfter > c.settings.MaxDelay {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+	delay := c.settings.BaseDelay << retry
+	if delay <= 0 || delay > c.settings.MaxDelay {
+		delay = c.settings.MaxDelay
+	}
+	if delay <= 0 {
+		return 0, true
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1), true
+}
+
+// isRetryable reports whether the error is a rate limit, server error or timeout
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
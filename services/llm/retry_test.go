@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// scriptedClient returns the scripted errors in order and then succeeds
+type scriptedClient struct {
+	errors []error
+	calls  int
+}
+
+func (c *scriptedClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	c.calls++
+	if c.calls <= len(c.errors) {
+		return nil, c.errors[c.calls-1]
+	}
+	return &Completion{Content: "ok", Model: config.Model}, nil
+}
+
+func (c *scriptedClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	completion, err := c.Complete(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return completion, onDelta(completion.Content)
+}
+
+var testRetry = RetrySettings{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryClient(t *testing.T) {
+	serverError := &APIError{StatusCode: http.StatusInternalServerError}
+	tests := []struct {
+		name    string
+		errors  []error
+		wantErr bool
+		calls   int
+	}{
+		{"success", nil, false, 1},
+		{"recovers from server errors", []error{serverError, serverError}, false, 3},
+		{"gives up after the retries", []error{serverError, serverError, serverError}, true, 3},
+		{"bad request is not retried", []error{&APIError{StatusCode: http.StatusBadRequest}}, true, 1},
+		{"timeout is retried", []error{context.DeadlineExceeded}, false, 2},
+		{"short Retry-After is honoured", []error{&APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}}, false, 2},
+		{"long Retry-After fails fast", []error{&APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}}, true, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next := &scriptedClient{errors: test.errors}
+			_, err := newRetryClient(next, testRetry).Complete(context.Background(), models.ModelConfig{})
+			if (err != nil) != test.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if next.calls != test.calls {
+				t.Errorf("calls = %d, want %d", next.calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestRetryClientDoesNotReplayStartedStreams(t *testing.T) {
+	next := &failingStream{}
+	_, err := newRetryClient(next, testRetry).Stream(context.Background(), models.ModelConfig{}, func(string) error { return nil })
+	if err == nil || next.calls != 1 {
+		t.Errorf("Stream() error = %v after %d calls, want the error after 1 call", err, next.calls)
+	}
+}
+
+// failingStream sends a delta and then fails with a server error
+type failingStream struct {
+	calls int
+}
+
+func (c *failingStream) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	return nil, errors.New("not used")
+}
+
+func (c *failingStream) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	c.calls++
+	onDelta("partial")
+	return nil, &APIError{StatusCode: http.StatusBadGateway}
+}
+
+func TestBreakerClient(t *testing.T) {
+	serverError := &APIError{StatusCode: http.StatusServiceUnavailable}
+	next := &scriptedClient{errors: []error{serverError, serverError}}
+	breaker := newBreakerClient(next, BreakerSettings{FailureThreshold: 2, Cooldown: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := breaker.Complete(ctx, models.ModelConfig{}); !errors.Is(err, serverError) {
+			t.Fatalf("call %d error = %v, want the server error", i+1, err)
+		}
+	}
+	if _, err := breaker.Complete(ctx, models.ModelConfig{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if next.calls != 2 {
+		t.Errorf("calls = %d, want 2, the open circuit must not reach the provider", next.calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := breaker.Complete(ctx, models.ModelConfig{}); err != nil {
+		t.Fatalf("trial call error = %v", err)
+	}
+	if _, err := breaker.Complete(ctx, models.ModelConfig{}); err != nil {
+		t.Errorf("error after a successful trial = %v, want the circuit closed", err)
+	}
+}
+
+func TestBreakerIgnoresClientErrors(t *testing.T) {
+	badRequest := &APIError{StatusCode: http.StatusBadRequest}
+	next := &scriptedClient{errors: []error{badRequest, badRequest, badRequest}}
+	breaker := newBreakerClient(next, BreakerSettings{FailureThreshold: 2, Cooldown: time.Hour})
+	for i := 0; i < 3; i++ {
+		if _, err := breaker.Complete(context.Background(), models.ModelConfig{}); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: circuit opened on client errors", i+1)
+		}
+	}
+}
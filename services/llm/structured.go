@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CompleteJSON asks the model for a JSON answer, decodes it into target and
+// validates it. When the answer is not valid JSON or fails validation the
+// model is shown its answer with the problem and asked again, up to attempts times.
+// The usage of the returned completion includes the rejected attempts.
+func CompleteJSON(ctx context.Context, client Client, config models.ModelConfig, schema string, target interface{}, validate func() error, attempts int) (*Completion, error) {
+	config.ResponseFormat = &models.ResponseFormat{Type: "json_object"}
+	config.Messages = append([]models.Message{}, config.Messages...)
+	if len(config.Messages) > 0 && config.Messages[0].Role == "system" {
+		config.Messages[0].Content += "\n\nRespond only with a JSON object matching this schema, without any other text:\n" + schema
+	}
+
+	var lastErr error
+	var rejected models.TokenUsage
+	for attempt := 0; attempt < attempts; attempt++ {
+		completion, err := client.Complete(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+
+		lastErr = decodeJSONAnswer(completion.Content, target)
+		if lastErr == nil {
+			lastErr = validate()
+		}
+		if lastErr == nil {
+			if rejected.TotalTokens > 0 {
+				// only the rejected attempts are billed when the answer came from the cache
+				if completion.Cached {
+					completion.Usage = models.TokenUsage{}
+					completion.Cached = false
+				}
+				completion.Usage = addTokenUsage(completion.Usage, rejected)
+			}
+			return completion, nil
+		}
+		if !completion.Cached {
+			rejected = addTokenUsage(rejected, completion.Usage)
+		}
+
+		config.Messages = append(config.Messages,
+			models.Message{Role: "assistant", Content: completion.Content},
+			models.Message{Role: "user", Content: fmt.Sprintf("Your answer was rejected: %v. Reply again with only a JSON object matching this schema:\n%s", lastErr, schema)},
+		)
+	}
+	return nil, fmt.Errorf("model did not return valid JSON after %d attempts: %w", attempts, lastErr)
+}
+
+// addTokenUsage sums the usage of two model calls
+func addTokenUsage(a models.TokenUsage, b models.TokenUsage) models.TokenUsage {
+	return models.TokenUsage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+		Estimated:        a.Estimated || b.Estimated,
+	}
+}
+
+// decodeJSONAnswer decodes the JSON object of an answer, ignoring code fences
+// or text the model put around it
+func decodeJSONAnswer(content string, target interface{}) error {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("the answer does not contain a JSON object")
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), target); err != nil {
+		return fmt.Errorf("the answer is not valid JSON: %v", err)
+	}
+	return nil
+}
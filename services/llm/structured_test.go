@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"aiagent/models"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// answerClient answers with the scripted completions in order
+type answerClient struct {
+	answers []Completion
+	calls   int
+}
+
+func (c *answerClient) Complete(ctx context.Context, config models.ModelConfig) (*Completion, error) {
+	answer := c.answers[c.calls]
+	c.calls++
+	return &answer, nil
+}
+
+func (c *answerClient) Stream(ctx context.Context, config models.ModelConfig, onDelta func(delta string) error) (*Completion, error) {
+	return c.Complete(ctx, config)
+}
+
+func TestCompleteJSONUsage(t *testing.T) {
+	usage := models.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	tests := []struct {
+		name       string
+		answers    []Completion
+		wantErr    bool
+		wantUsage  models.TokenUsage
+		wantCached bool
+	}{
+		{"first answer", []Completion{{Content: `{"ok": true}`, Usage: usage}}, false, usage, false},
+		{"rejected answers are added", []Completion{{Content: "no json", Usage: usage}, {Content: `{"ok": false}`, Usage: usage}, {Content: `{"ok": true}`, Usage: usage}}, false, models.TokenUsage{PromptTokens: 30, CompletionTokens: 15, TotalTokens: 45}, false},
+		{"cached answer", []Completion{{Content: `{"ok": true}`, Usage: usage, Cached: true}}, false, usage, true},
+		{"cached answer after a rejection", []Completion{{Content: "no json", Usage: usage}, {Content: `{"ok": true}`, Usage: usage, Cached: true}}, false, usage, false},
+		{"every answer rejected", []Completion{{Content: "no json", Usage: usage}, {Content: "no json", Usage: usage}, {Content: "no json", Usage: usage}}, true, models.TokenUsage{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var answer struct {
+				OK bool `json:"ok"`
+			}
+			validate := func() error {
+				if !answer.OK {
+					return fmt.Errorf("ok must be true")
+				}
+				return nil
+			}
+			config := models.ModelConfig{Messages: []models.Message{{Role: "system", Content: "answer"}}}
+			completion, err := CompleteJSON(context.Background(), &answerClient{answers: test.answers}, config, `{"ok": "boolean"}`, &answer, validate, 3)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("CompleteJSON() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if completion.Usage != test.wantUsage || completion.Cached != test.wantCached {
+				t.Errorf("usage = %+v cached %v, want %+v cached %v", completion.Usage, completion.Cached, test.wantUsage, test.wantCached)
+			}
+		})
+	}
+}
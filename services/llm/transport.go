@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"aiagent/models"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Streaming responses can legitimately take longer than the timeout, so the
+// deadline is applied per request through the context instead of the client.
+var httpClient = &http.Client{}
+
+// postJSON sends the payload to the endpoint and validates the status code
+func postJSON(ctx context.Context, uri string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling config: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return resp, nil
+}
+
+// decodeJSON reads the whole response body into target
+func decodeJSON(resp *http.Response, target interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	return nil
+}
+
+// scanLines calls onLine for every non empty line of a streamed response body.
+// Lines are read without a length limit, a single chunk can exceed 64KB.
+func scanLines(body io.Reader, onLine func(line string) (bool, error)) error {
+	reader := bufio.NewReader(body)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			done, err := onLine(line)
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("error reading stream: %w", readErr)
+		}
+	}
+}
+
+// sseData returns the payload of a Server-Sent Events "data:" line
+func sseData(line string) (string, bool) {
+	if !strings.HasPrefix(line, "data:") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "data:")), true
+}
+
+// modelName prefers the model reported by the provider over the requested one
+func modelName(reported string, requested string) string {
+	if reported != "" {
+		return reported
+	}
+	return requested
+}
+
+// splitSystemMessages separates system instructions from the conversation,
+// for providers that take the system prompt outside the message list
+func splitSystemMessages(messages []models.Message) (string, []models.Message) {
+	var system []string
+	var conversation []models.Message
+	for _, message := range messages {
+		if message.Role == "system" {
+			if message.Content != "" {
+				system = append(system, message.Content)
+			}
+			continue
+		}
+		conversation = append(conversation, message)
+	}
+	return strings.Join(system, "\n\n"), conversation
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+func newTokenUsage(promptTokens int, completionTokens int) models.TokenUsage {
+	return models.TokenUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// ensureUsage estimates the token usage when the provider did not report it
+func ensureUsage(config models.ModelConfig, completion *Completion) {
+	if completion.Usage.TotalTokens > 0 {
+		return
+	}
+	promptTokens := 0
+	for _, message := range config.Messages {
+		promptTokens += CountTokens(message.Content)
+	}
+	completion.Usage = newTokenUsage(promptTokens, CountTokens(completion.Content))
+	completion.Usage.Estimated = true
+}
@@ -0,0 +1,169 @@
+package scraper
+
+import (
+	"aiagent/repository"
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type forceRefreshKey struct{}
+
+// WithForceRefresh makes the scrapes of the context bypass the cache
+func WithForceRefresh(ctx context.Context, force bool) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, force)
+}
+
+func forceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+// cachedPage is the document stored in the scrape cache collection
+type cachedPage struct {
+	Key       string    `bson:"_id"`
+	Page      Page      `bson:"page"`
+	FetchedAt time.Time `bson:"fetched_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// cacheScraper reuses pages scraped within the TTL and makes concurrent
+// scrapes of the same URL wait for a single fetch
+type cacheScraper struct {
+	next Scraper
+	repo repository.Repository
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]*inflightScrape
+}
+
+type inflightScrape struct {
+	done chan struct{}
+	page *Page
+	err  error
+	// cancelled is set when the scrape failed because its caller went away
+	cancelled bool
+}
+
+// NewCache wraps the scraper with a cache stored in repo
+func NewCache(next Scraper, repo repository.Repository, ttl time.Duration) Scraper {
+	return &cacheScraper{next: next, repo: repo, ttl: ttl, inflight: map[string]*inflightScrape{}}
+}
+
+func (s *cacheScraper) Scrape(ctx context.Context, rawURL string) (*Page, error) {
+	key := NormalizeURL(rawURL)
+	if !forceRefresh(ctx) {
+		var cached cachedPage
+		err := s.repo.FindOne(bson.M{"_id": key, "fetched_at": bson.M{"$gt": time.Now().Add(-s.ttl)}}).Decode(&cached)
+		if err == nil {
+			return &cached.Page, nil
+		}
+	}
+
+	for {
+		s.mu.Lock()
+		call, exists := s.inflight[key]
+		if !exists {
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// A scrape cancelled by its caller is fetched again for the callers still waiting
+		if !call.cancelled {
+			return call.page, call.err
+		}
+	}
+	call := &inflightScrape{done: make(chan struct{})}
+	s.inflight[key] = call
+	s.mu.Unlock()
+
+	call.page, call.err = s.next.Scrape(ctx, rawURL)
+	call.cancelled = call.err != nil && ctx.Err() != nil
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(call.done)
+
+	if call.err == nil {
+		s.store(key, call.page)
+	}
+	return call.page, call.err
+}
+
+func (s *cacheScraper) store(key string, page *Page) {
+	entry := cachedPage{
+		Key:       key,
+		Page:      *page,
+		FetchedAt: time.Now(),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	err := s.repo.UpdateOne(bson.M{"_id": key}, bson.M{"$set": entry}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Warn("Error storing scraped page in cache: ", err)
+	}
+}
+
+// trackingParams are dropped from URLs before they are used as cache keys
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true,
+	"ref": true, "ref_src": true, "trk": true, "trackingid": true,
+}
+
+// NormalizeURL returns a stable form of the URL: lower case scheme and host,
+// no default port, fragment, tracking parameters or trailing slash, and
+// sorted query parameters
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && !(parsed.Scheme == "http" && port == "80") && !(parsed.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+
+	query := parsed.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var encoded []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			encoded = append(encoded, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	parsed.RawQuery = strings.Join(encoded, "&")
+	return parsed.String()
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://acme.com", "https://acme.com"},
+		{"acme.com/about/", "https://acme.com/about"},
+		{"HTTPS://Acme.COM:443/About", "https://acme.com/About"},
+		{"http://acme.com:80/", "http://acme.com"},
+		{"http://acme.com:8080/", "http://acme.com:8080"},
+		{"https://acme.com/about#team", "https://acme.com/about"},
+		{"https://acme.com/?utm_source=x&UTM_Medium=y", "https://acme.com"},
+		{"https://acme.com/?b=2&a=1&gclid=z", "https://acme.com?a=1&b=2"},
+		{"https://acme.com/?a=2&a=1", "https://acme.com?a=1&a=2"},
+		{"  https://acme.com/news  ", "https://acme.com/news"},
+	}
+	for _, test := range tests {
+		if got := NormalizeURL(test.raw); got != test.want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", test.raw, got, test.want)
+		}
+	}
+}
+
+// emptyRepo never finds a cached page and drops what is stored
+type emptyRepo struct{}
+
+func (emptyRepo) FindOne(filter primitive.M) *mongo.SingleResult {
+	return mongo.NewSingleResultFromDocument(bson.M{}, mongo.ErrNoDocuments, nil)
+}
+func (r emptyRepo) FindOneWithOptions(filter primitive.M, options *options.FindOneOptions) *mongo.SingleResult {
+	return r.FindOne(filter)
+}
+func (emptyRepo) InsertOne(document interface{}) (interface{}, error) { return nil, nil }
+func (emptyRepo) DeleteMany(filter primitive.M)                       {}
+func (emptyRepo) UpdateOne(filter primitive.M, update primitive.M, updateOptions *options.UpdateOptions) error {
+	return nil
+}
+func (emptyRepo) UpdateMany(filter primitive.M, update primitive.M) error { return nil }
+func (emptyRepo) Find(filter primitive.M) (*mongo.Cursor, error)          { return nil, nil }
+func (emptyRepo) FindWithOption(filter primitive.M, option *options.FindOptions) (*mongo.Cursor, error) {
+	return nil, nil
+}
+func (emptyRepo) InsertMany(document []interface{}, insertOptions *options.InsertManyOptions) ([]interface{}, error) {
+	return nil, nil
+}
+
+// blockingScraper answers once release is closed or fails when the caller goes away
+type blockingScraper struct {
+	release chan struct{}
+	started chan struct{}
+}
+
+func (s *blockingScraper) Scrape(ctx context.Context, rawURL string) (*Page, error) {
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+		return &Page{URL: rawURL, Content: "page"}, nil
+	case <-ctx.Done():
+		return nil, requestError(rawURL, ctx.Err())
+	}
+}
+
+func TestCancelledScrapeIsRetriedForWaiters(t *testing.T) {
+	next := &blockingScraper{release: make(chan struct{}), started: make(chan struct{}, 2)}
+	cache := NewCache(next, emptyRepo{}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := cache.Scrape(ctx, "https://acme.com"); err == nil {
+			t.Error("cancelled Scrape() succeeded")
+		}
+	}()
+	<-next.started
+
+	var page *Page
+	var err error
+	go func() {
+		defer wg.Done()
+		page, err = cache.Scrape(context.Background(), "https://acme.com/")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-next.started:
+	case <-time.After(time.Second):
+		t.Fatal("the waiting scrape was not retried")
+	}
+	close(next.release)
+	wg.Wait()
+	if err != nil || page == nil || page.Content != "page" {
+		t.Errorf("waiting Scrape() = %v, %v, want the page", page, err)
+	}
+}
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// boilerplate matches short lines that only exist to operate the site
+var boilerplate = regexp.MustCompile(`(?i)(we use cookies|this (web)?site uses cookies|accept (all )?cookies|cookie (policy|settings|preferences|notice)|manage (cookies|consent)|skip to (main )?content|all rights reserved|^(©|copyright)|privacy policy|terms (of|and) (use|service|conditions)|^(sign in|sign up|log ?in|register|menu|search|close|toggle navigation)$|subscribe to our newsletter|follow us( on)?|share (on|this)|back to top|^(facebook|twitter|linkedin|instagram|youtube|x)$)`)
+
+// maxBoilerplateLine is the longest line that can be dropped as boilerplate,
+// longer lines are content that happens to mention cookies or privacy
+const maxBoilerplateLine = 160
+
+// minMenuRun is the number of consecutive link-like lines treated as a menu
+const minMenuRun = 5
+
+// cleaningScraper cleans the content of every page of the next scraper and
+// keeps the original in RawContent
+type cleaningScraper struct {
+	next     Scraper
+	maxBytes int
+}
+
+// NewCleaner wraps the scraper with the cleaning stage, cleaned content is
+// capped to maxBytes
+func NewCleaner(next Scraper, maxBytes int) Scraper {
+	return &cleaningScraper{next: next, maxBytes: maxBytes}
+}
+
+func (s *cleaningScraper) Scrape(ctx context.Context, url string) (*Page, error) {
+	page, err := s.next.Scrape(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	page.RawContent = page.Content
+	page.Content = Clean(page.Content, s.maxBytes)
+	if page.Content == "" {
+		return nil, emptyError(url)
+	}
+	return page, nil
+}
+
+// Clean removes cookie banners, menus and other boilerplate lines, drops
+// repeated lines, normalizes whitespace and caps the text to maxBytes at a line
+// boundary. A maxBytes of zero disables the cap.
+func Clean(text string, maxBytes int) string {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		line = collapseSpaces(line)
+		if line == "" {
+			// Keep a single blank line between sections
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		if len(line) <= maxBoilerplateLine && boilerplate.MatchString(line) {
+			continue
+		}
+		key := strings.ToLower(line)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		lines = append(lines, line)
+	}
+	lines = dropMenus(lines)
+
+	var builder strings.Builder
+	for _, line := range lines {
+		if maxBytes > 0 && builder.Len()+len(line)+1 > maxBytes {
+			if builder.Len() == 0 {
+				builder.WriteString(truncateUTF8(line, maxBytes))
+			}
+			break
+		}
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(line)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// dropMenus removes runs of consecutive link-like lines, which are navigation
+// menus and footer link lists
+func dropMenus(lines []string) []string {
+	var cleaned []string
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && isLinkLike(lines[i]) {
+			continue
+		}
+		if i-start < minMenuRun {
+			cleaned = append(cleaned, lines[start:i]...)
+		}
+		if i < len(lines) {
+			cleaned = append(cleaned, lines[i])
+		}
+		start = i + 1
+	}
+	return cleaned
+}
+
+// isLinkLike tells whether the line looks like a menu entry: at most three
+// words without sentence punctuation
+func isLinkLike(line string) bool {
+	return line != "" && len(strings.Fields(line)) <= 3 && !strings.ContainsAny(line, ".:;!?")
+}
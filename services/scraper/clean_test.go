@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxBytes int
+		want     string
+	}{
+		{
+			name: "boilerplate",
+			text: "We use cookies to improve your experience\nAcme builds rockets for small satellites.\n© 2024 Acme Inc. All rights reserved",
+			want: "Acme builds rockets for small satellites.",
+		},
+		{
+			name: "long lines mentioning cookies are content",
+			text: "Our bakery has sold cookies since 1950, and we use cookies from local farms in every box we ship to customers across the country, from coast to coast, every single day of the week.",
+			want: "Our bakery has sold cookies since 1950, and we use cookies from local farms in every box we ship to customers across the country, from coast to coast, every single day of the week.",
+		},
+		{
+			name: "repeated lines",
+			text: "Acme builds rockets.\nWe ship worldwide.\nacme builds rockets.",
+			want: "Acme builds rockets.\nWe ship worldwide.",
+		},
+		{
+			name: "whitespace",
+			text: "  Acme\t builds   rockets.  \n\n\n\nWe ship worldwide.",
+			want: "Acme builds rockets.\n\nWe ship worldwide.",
+		},
+		{
+			name: "menu run",
+			text: "Home\nProducts\nPricing\nAbout us\nContact\nAcme builds rockets.",
+			want: "Acme builds rockets.",
+		},
+		{
+			name: "short run is kept",
+			text: "Rockets\nSatellites\nAcme builds rockets.",
+			want: "Rockets\nSatellites\nAcme builds rockets.",
+		},
+		{
+			name:     "cap at a line boundary",
+			text:     "First line.\nSecond line.\nThird line.",
+			maxBytes: 25,
+			want:     "First line.\nSecond line.",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Clean(test.text, test.maxBytes); got != test.want {
+				t.Errorf("Clean() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCleanCapsSingleLongLine(t *testing.T) {
+	got := Clean(strings.Repeat("é", 100), 11)
+	if len(got) > 11 || !strings.HasPrefix(strings.Repeat("é", 100), got) {
+		t.Errorf("Clean() = %q, want at most 11 bytes of whole characters", got)
+	}
+}
@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CrawlBudget bounds the work done for a single site
+type CrawlBudget struct {
+	// MaxPages includes the home page
+	MaxPages int
+	// MaxBytes limits the combined content of all pages
+	MaxBytes int
+}
+
+// CrawlBudgetFromEnv reads CRAWL_MAX_PAGES, 6 by default, and CRAWL_MAX_BYTES,
+// 60000 by default
+func CrawlBudgetFromEnv() CrawlBudget {
+	budget := CrawlBudget{MaxPages: 6, MaxBytes: 60000}
+	if value, err := strconv.Atoi(os.Getenv("CRAWL_MAX_PAGES")); err == nil && value > 0 {
+		budget.MaxPages = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("CRAWL_MAX_BYTES")); err == nil && value > 0 {
+		budget.MaxBytes = value
+	}
+	return budget
+}
+
+// Site is the result of crawling a company website
+type Site struct {
+	URL string
+	// Pages are the URLs whose content was used, home page first
+	Pages []string
+	// Content combines the content of every page under a section header
+	Content string
+}
+
+// siteSection is a kind of page worth reading on a company website
+type siteSection struct {
+	Name string
+	// Keywords are matched against the path of the links of the home page
+	Keywords []string
+	// Path is tried when the home page has no links, e.g. with the remote scraper
+	Path string
+}
+
+// siteSections are crawled in this order after the home page
+var siteSections = []siteSection{
+	{Name: "About", Keywords: []string{"about", "who-we-are", "company", "our-story"}, Path: "/about"},
+	{Name: "Products", Keywords: []string{"product", "solution", "platform", "service", "feature"}, Path: "/products"},
+	{Name: "Customers", Keywords: []string{"customer", "case-stud", "client", "success-stor"}, Path: "/customers"},
+	{Name: "News", Keywords: []string{"news", "blog", "press", "insight"}, Path: "/blog"},
+	{Name: "Careers", Keywords: []string{"career", "jobs", "join-us", "hiring"}, Path: "/careers"},
+}
+
+// Crawler reads the key pages of a company website
+type Crawler struct {
+	scraper Scraper
+	budget  CrawlBudget
+}
+
+// NewCrawler returns a crawler that fetches every page with scraper
+func NewCrawler(scraper Scraper, budget CrawlBudget) *Crawler {
+	return &Crawler{scraper: scraper, budget: budget}
+}
+
+// Crawl scrapes the home page and then one page per section, within the budget.
+// Only a failure of the home page is returned, failed sections are skipped.
+func (c *Crawler) Crawl(ctx context.Context, homeURL string) (*Site, error) {
+	home, err := c.scraper.Scrape(ctx, homeURL)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(firstNonEmpty(home.URL, homeURL))
+	if err != nil {
+		return nil, &Error{Kind: ErrUpstream, URL: homeURL, Err: fmt.Errorf("invalid home page URL: %w", err)}
+	}
+
+	site := &Site{URL: base.String()}
+	var content strings.Builder
+	add := func(name string, page *Page) bool {
+		remaining := c.budget.MaxBytes - content.Len()
+		if remaining <= 0 {
+			return false
+		}
+		text := fmt.Sprintf("== %s (%s) ==\n%s\n\n", name, page.URL, page.Content)
+		if len(text) > remaining {
+			text = truncateUTF8(text, remaining)
+		}
+		content.WriteString(text)
+		site.Pages = append(site.Pages, page.URL)
+		return content.Len() < c.budget.MaxBytes
+	}
+
+	if add("Home", home) {
+		visited := map[string]bool{NormalizeURL(home.URL): true, NormalizeURL(homeURL): true}
+		for _, section := range siteSections {
+			if len(site.Pages) >= c.budget.MaxPages || ctx.Err() != nil {
+				break
+			}
+			link := sectionLink(base, home.Links, section)
+			if link == "" || visited[NormalizeURL(link)] {
+				continue
+			}
+			visited[NormalizeURL(link)] = true
+
+			page, err := c.scraper.Scrape(ctx, link)
+			if err != nil {
+				log.Debug("Skipping ", section.Name, " page of ", site.URL, ": ", err)
+				continue
+			}
+			if !add(section.Name, page) {
+				break
+			}
+		}
+	}
+
+	site.Content = strings.TrimSpace(content.String())
+	return site, nil
+}
+
+// sectionLink returns the first link of the home page on the same site that
+// matches the section, or the usual path of the section when the home page has no links
+func sectionLink(base *url.URL, links []string, section siteSection) string {
+	if len(links) == 0 {
+		return base.ResolveReference(&url.URL{Path: section.Path}).String()
+	}
+	host := strings.TrimPrefix(strings.ToLower(base.Hostname()), "www.")
+	for _, link := range links {
+		parsed, err := url.Parse(link)
+		if err != nil || strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.") != host {
+			continue
+		}
+		path := strings.ToLower(parsed.Path)
+		for _, keyword := range section.Keywords {
+			if strings.Contains(path, keyword) {
+				return link
+			}
+		}
+	}
+	return ""
+}
+
+// truncateUTF8 cuts text to at most limit bytes without splitting a rune
+func truncateUTF8(text string, limit int) string {
+	for limit > 0 && limit < len(text) && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorKind tells why a page could not be scraped
+type ErrorKind string
+
+// Kinds of scrape failures
+const (
+	// ErrNotFound is returned when the page does not exist
+	ErrNotFound ErrorKind = "not_found"
+	// ErrBlocked is returned when the site refused to serve the page
+	ErrBlocked ErrorKind = "blocked"
+	// ErrTimeout is returned when the page did not answer in time
+	ErrTimeout ErrorKind = "timeout"
+	// ErrDisallowed is returned when robots.txt does not allow the page to be fetched
+	ErrDisallowed ErrorKind = "disallowed"
+	// ErrEmptyContent is returned when the page has no readable text
+	ErrEmptyContent ErrorKind = "empty_content"
+	// ErrUpstream is returned for every other failure of the site or scraper service
+	ErrUpstream ErrorKind = "upstream_failure"
+)
+
+// Error is returned by every scraper
+type Error struct {
+	Kind ErrorKind
+	URL  string
+	// StatusCode is the HTTP status received, zero when none was received
+	StatusCode int
+	// RetryAfter is the delay requested with a 429 or 503 answer, zero when not sent
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("scraping %s failed (%s, status %d): %v", e.URL, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("scraping %s failed (%s): %v", e.URL, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of a scrape error, ErrUpstream for untyped errors
+// and an empty kind for nil
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var scrapeErr *Error
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Kind
+	}
+	return ErrUpstream
+}
+
+// requestError classifies an error returned while sending the request or reading the answer
+func requestError(url string, err error) error {
+	kind := ErrUpstream
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		kind = ErrTimeout
+	}
+	return &Error{Kind: kind, URL: url, Err: err}
+}
+
+// statusError classifies a non 200 answer
+func statusError(url string, resp *http.Response) error {
+	statusCode := resp.StatusCode
+	kind := ErrUpstream
+	switch statusCode {
+	case http.StatusNotFound, http.StatusGone:
+		kind = ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusUnavailableForLegalReasons, 999:
+		// LinkedIn answers 999 to requests it considers automated
+		kind = ErrBlocked
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		kind = ErrTimeout
+	}
+	scrapeErr := &Error{Kind: kind, URL: url, StatusCode: statusCode, Err: fmt.Errorf("page responded with status %d", statusCode)}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		scrapeErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return scrapeErr
+}
+
+// emptyError is returned when a page was fetched but holds no readable text
+func emptyError(url string) error {
+	return &Error{Kind: ErrEmptyContent, URL: url, Err: fmt.Errorf("no content found on the page")}
+}
@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxPageBytes limits how much of a page is read
+const maxPageBytes = 5 << 20
+
+// userAgent identifies the native scraper to the sites it fetches
+const userAgent = "Mozilla/5.0 (compatible; aiagent/1.0; +https://initializ.ai)"
+
+// nativeScraper fetches pages itself and extracts their readable text
+type nativeScraper struct {
+	httpClient *http.Client
+}
+
+// NewNative returns a scraper that needs no external service, every page
+// must be fetched within timeout
+func NewNative(timeout time.Duration) Scraper {
+	return &nativeScraper{httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (s *nativeScraper) Scrape(ctx context.Context, url string) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while making the scrape request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, requestError(url, fmt.Errorf("error occurred while fetching the page: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(url, resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, requestError(url, fmt.Errorf("error occurred while reading the page: %w", err))
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		text := strings.TrimSpace(string(body))
+		if text == "" {
+			return nil, emptyError(url)
+		}
+		return &Page{URL: resp.Request.URL.String(), Text: text, Content: text}, nil
+	}
+
+	page, err := ExtractPage(strings.NewReader(string(body)))
+	if err != nil {
+		return nil, &Error{Kind: ErrUpstream, URL: url, Err: err}
+	}
+	page.URL = resp.Request.URL.String()
+	page.Links = resolveLinks(resp.Request.URL, page.Links)
+	if page.Content == "" {
+		return nil, emptyError(url)
+	}
+	return page, nil
+}
+
+// skippedElements never contain readable text
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Svg: true,
+	atom.Iframe: true, atom.Template: true, atom.Canvas: true, atom.Object: true,
+}
+
+// blockElements end a line of text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Nav: true, atom.Aside: true, atom.Li: true,
+	atom.Ul: true, atom.Ol: true, atom.Tr: true, atom.Table: true, atom.Br: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Dd: true, atom.Dt: true, atom.Figcaption: true,
+}
+
+// ExtractPage reads the title, meta description, headings and main text of an HTML document
+func ExtractPage(document io.Reader) (*Page, error) {
+	root, err := html.Parse(document)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while parsing the page: %w", err)
+	}
+
+	page := &Page{}
+	var body, main *html.Node
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.DataAtom {
+			case atom.Title:
+				if page.Title == "" {
+					page.Title = collapseSpaces(nodeText(node))
+				}
+			case atom.Meta:
+				name := strings.ToLower(attribute(node, "name") + attribute(node, "property"))
+				if page.Description == "" && (name == "description" || name == "og:description") {
+					page.Description = collapseSpaces(attribute(node, "content"))
+				}
+			case atom.H1, atom.H2, atom.H3:
+				if heading := collapseSpaces(nodeText(node)); heading != "" {
+					page.Headings = append(page.Headings, heading)
+				}
+			case atom.A:
+				if href := strings.TrimSpace(attribute(node, "href")); href != "" {
+					page.Links = append(page.Links, href)
+				}
+			case atom.Body:
+				body = node
+			case atom.Main, atom.Article:
+				if main == nil {
+					main = node
+				}
+			}
+			if skippedElements[node.DataAtom] {
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	content := main
+	if content == nil {
+		content = body
+	}
+	if content != nil {
+		page.Text = readableText(content)
+	}
+
+	var combined []string
+	if page.Title != "" {
+		combined = append(combined, "Title: "+page.Title)
+	}
+	if page.Description != "" {
+		combined = append(combined, "Description: "+page.Description)
+	}
+	if len(page.Headings) > 0 {
+		combined = append(combined, "Headings: "+strings.Join(page.Headings, " | "))
+	}
+	if page.Text != "" {
+		combined = append(combined, page.Text)
+	}
+	page.Content = strings.Join(combined, "\n\n")
+	return page, nil
+}
+
+// readableText renders the text of the node with one line per block element
+func readableText(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			builder.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[node.DataAtom] {
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode && blockElements[node.DataAtom] {
+			builder.WriteString("\n")
+		}
+	}
+	walk(node)
+
+	var lines []string
+	for _, line := range strings.Split(builder.String(), "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resolveLinks makes the links absolute and drops duplicates, fragments and
+// links that are not http or https
+func resolveLinks(base *neturl.URL, links []string) []string {
+	seen := make(map[string]bool)
+	var resolved []string
+	for _, link := range links {
+		parsed, err := base.Parse(link)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			continue
+		}
+		parsed.Fragment = ""
+		if absolute := parsed.String(); !seen[absolute] {
+			seen[absolute] = true
+			resolved = append(resolved, absolute)
+		}
+	}
+	return resolved
+}
+
+func nodeText(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+			builder.WriteString(" ")
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return builder.String()
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
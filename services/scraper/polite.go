@@ -0,0 +1,350 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PolitenessSettings limits the traffic sent to every host
+type PolitenessSettings struct {
+	// MaxConcurrent is the number of concurrent requests allowed across all hosts
+	MaxConcurrent int
+	// MaxPerHost is the number of concurrent requests allowed per host
+	MaxPerHost int
+	// MinDelay separates the start of two requests to the same host
+	MinDelay time.Duration
+	// Backoff is the first pause after a 429, doubled on every 429 in a row up to
+	// MaxBackoff, which also caps Retry-After
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxRetries is the number of times a page answered with 429 is fetched again
+	MaxRetries int
+	// RespectRobots enables robots.txt checks
+	RespectRobots bool
+	// RobotsTTL is how long a robots.txt is kept in memory
+	RobotsTTL time.Duration
+}
+
+// PolitenessFromEnv reads SCRAPE_MAX_CONCURRENT (8), SCRAPE_MAX_PER_HOST (2), SCRAPE_MIN_DELAY_MS (1000),
+// SCRAPE_BACKOFF_SECONDS (30), SCRAPE_MAX_BACKOFF_SECONDS (600),
+// SCRAPE_MAX_RETRIES (2) and SCRAPE_RESPECT_ROBOTS (true)
+func PolitenessFromEnv() PolitenessSettings {
+	return PolitenessSettings{
+		MaxConcurrent: envInt("SCRAPE_MAX_CONCURRENT", 8),
+		MaxPerHost:    envInt("SCRAPE_MAX_PER_HOST", 2),
+		MinDelay:      time.Duration(envInt("SCRAPE_MIN_DELAY_MS", 1000)) * time.Millisecond,
+		Backoff:       time.Duration(envInt("SCRAPE_BACKOFF_SECONDS", 30)) * time.Second,
+		MaxBackoff:    time.Duration(envInt("SCRAPE_MAX_BACKOFF_SECONDS", 600)) * time.Second,
+		MaxRetries:    envInt("SCRAPE_MAX_RETRIES", 2),
+		RespectRobots: !strings.EqualFold(os.Getenv("SCRAPE_RESPECT_ROBOTS"), "false"),
+		RobotsTTL:     24 * time.Hour,
+	}
+}
+
+// hostState tracks the requests sent to a single host
+type hostState struct {
+	slots chan struct{}
+
+	mu        sync.Mutex
+	nextStart time.Time
+	backoff   time.Duration
+
+	robots        *robotsRules
+	robotsExpires time.Time
+	// robotsFetch is closed once the robots.txt being fetched is stored
+	robotsFetch chan struct{}
+}
+
+// Gate checks robots.txt and spaces out the requests sent to every host. It
+// is shared by everything that fetches pages of other sites, so the limits
+// hold across the scraper and the website resolver.
+type Gate struct {
+	settings   PolitenessSettings
+	httpClient *http.Client
+	// slots is shared by all hosts
+	slots chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// NewGate returns a gate applying the settings
+func NewGate(settings PolitenessSettings) *Gate {
+	if settings.MaxPerHost <= 0 {
+		settings.MaxPerHost = 1
+	}
+	if settings.MaxConcurrent < settings.MaxPerHost {
+		settings.MaxConcurrent = settings.MaxPerHost
+	}
+	return &Gate{
+		settings:   settings,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		slots:      make(chan struct{}, settings.MaxConcurrent),
+		hosts:      make(map[string]*hostState),
+	}
+}
+
+// Acquire checks robots.txt for the URL, then waits for a slot of its host,
+// its turn on the host and a slot shared by all hosts. The returned function
+// gives the slots back once the request is done.
+func (g *Gate) Acquire(ctx context.Context, rawURL string) (func(), error) {
+	parsed, host, err := g.hostOf(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if g.settings.RespectRobots {
+		rules, err := g.robots(ctx, host, parsed)
+		if err != nil {
+			return nil, requestError(rawURL, err)
+		}
+		// RequestURI is "/" for bare origins and keeps the query rules may match
+		if !rules.allowed(parsed.RequestURI()) {
+			return nil, &Error{Kind: ErrDisallowed, URL: rawURL, Err: fmt.Errorf("robots.txt of %s does not allow this page", parsed.Hostname())}
+		}
+	}
+
+	select {
+	case host.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, requestError(rawURL, ctx.Err())
+	}
+	if err := sleep(ctx, host.turn(g.settings.MinDelay)); err != nil {
+		<-host.slots
+		return nil, requestError(rawURL, err)
+	}
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		<-host.slots
+		return nil, requestError(rawURL, ctx.Err())
+	}
+	return func() {
+		<-g.slots
+		<-host.slots
+	}, nil
+}
+
+// BackOff pushes back the next request to the host of the URL after a 429
+// and returns the pause
+func (g *Gate) BackOff(rawURL string, retryAfter time.Duration) time.Duration {
+	_, host, err := g.hostOf(rawURL)
+	if err != nil {
+		return 0
+	}
+	return host.backOff(g.settings, retryAfter)
+}
+
+// Succeeded ends the backoff of the host of the URL
+func (g *Gate) Succeeded(rawURL string) {
+	if _, host, err := g.hostOf(rawURL); err == nil {
+		host.resetBackoff()
+	}
+}
+
+// politeScraper fetches every page through the gate and retries pages
+// answered with 429 after the backoff
+type politeScraper struct {
+	next Scraper
+	gate *Gate
+}
+
+// NewPolite wraps the scraper with the per host limits and robots.txt checks of the gate
+func NewPolite(next Scraper, gate *Gate) Scraper {
+	return &politeScraper{next: next, gate: gate}
+}
+
+func (s *politeScraper) Scrape(ctx context.Context, rawURL string) (*Page, error) {
+	settings := s.gate.settings
+	for attempt := 0; ; attempt++ {
+		release, err := s.gate.Acquire(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		page, err := s.next.Scrape(ctx, rawURL)
+		release()
+
+		var scrapeErr *Error
+		if err == nil || !errors.As(err, &scrapeErr) || scrapeErr.StatusCode != http.StatusTooManyRequests {
+			if err == nil {
+				s.gate.Succeeded(rawURL)
+			}
+			return page, err
+		}
+
+		delay := s.gate.BackOff(rawURL, scrapeErr.RetryAfter)
+		log.Warn("Host of ", rawURL, " answered 429, pausing for ", delay)
+		// A Retry-After longer than MaxBackoff would hold the caller for too long
+		tooLong := settings.MaxBackoff > 0 && scrapeErr.RetryAfter > settings.MaxBackoff
+		if attempt >= settings.MaxRetries || tooLong {
+			return nil, err
+		}
+	}
+}
+
+// hostOf returns the parsed URL and the state of its host, www. and the bare
+// domain share it
+func (g *Gate) hostOf(rawURL string) (*url.URL, *hostState, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, nil, &Error{Kind: ErrNotFound, URL: rawURL, Err: fmt.Errorf("invalid URL")}
+	}
+	key := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	state, exists := g.hosts[key]
+	if !exists {
+		state = &hostState{slots: make(chan struct{}, g.settings.MaxPerHost)}
+		g.hosts[key] = state
+	}
+	return parsed, state, nil
+}
+
+// robots returns the robots.txt rules of the host, fetching them when missing
+// or expired. The host is not locked during the fetch, concurrent callers wait
+// for the fetch in progress instead of starting their own.
+func (g *Gate) robots(ctx context.Context, host *hostState, page *url.URL) (*robotsRules, error) {
+	for {
+		host.mu.Lock()
+		if host.robots != nil && time.Now().Before(host.robotsExpires) {
+			rules := host.robots
+			host.mu.Unlock()
+			return rules, nil
+		}
+		fetching := host.robotsFetch
+		if fetching == nil {
+			break
+		}
+		host.mu.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	fetched := make(chan struct{})
+	host.robotsFetch = fetched
+	host.mu.Unlock()
+
+	robotsURL := &url.URL{Scheme: page.Scheme, Host: page.Host, Path: "/robots.txt"}
+	rules, answered := g.fetchRobots(ctx, robotsURL.String())
+
+	host.mu.Lock()
+	// Unanswered fetches are tried again next time rather than allowing everything for RobotsTTL
+	if answered {
+		host.robots = rules
+		host.robotsExpires = time.Now().Add(g.settings.RobotsTTL)
+	}
+	host.robotsFetch = nil
+	host.mu.Unlock()
+	close(fetched)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// fetchRobots allows everything when robots.txt is missing or unreachable and
+// nothing when the site fails with a server error. answered is false when the
+// site could not be reached.
+func (g *Gate) fetchRobots(ctx context.Context, robotsURL string) (rules *robotsRules, answered bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return allowAll, false
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		log.Debug("Could not fetch ", robotsURL, ": ", err)
+		return allowAll, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return parseRobots(resp.Body), true
+	case resp.StatusCode >= 500:
+		return disallowAll, true
+	}
+	return allowAll, true
+}
+
+// turn reserves the next start time of the host and returns how long to wait for it
+func (h *hostState) turn(minDelay time.Duration) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.robots != nil && h.robots.crawlDelay > minDelay {
+		minDelay = h.robots.crawlDelay
+	}
+	now := time.Now()
+	start := now
+	if h.nextStart.After(start) {
+		start = h.nextStart
+	}
+	h.nextStart = start.Add(minDelay)
+	return start.Sub(now)
+}
+
+// backOff pushes back the next start of the host after a 429 and returns the pause
+func (h *hostState) backOff(settings PolitenessSettings, retryAfter time.Duration) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.backoff == 0 {
+		h.backoff = settings.Backoff
+	} else {
+		h.backoff *= 2
+	}
+	if settings.MaxBackoff > 0 && h.backoff > settings.MaxBackoff {
+		h.backoff = settings.MaxBackoff
+	}
+	delay := h.backoff
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	if settings.MaxBackoff > 0 && delay > settings.MaxBackoff {
+		delay = settings.MaxBackoff
+	}
+	if next := time.Now().Add(delay); next.After(h.nextStart) {
+		h.nextStart = next
+	}
+	return delay
+}
+
+func (h *hostState) resetBackoff() {
+	h.mu.Lock()
+	h.backoff = 0
+	h.mu.Unlock()
+}
+
+// sleep waits for the delay or until the context is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// envInt reads a positive integer from the environment
+func envInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
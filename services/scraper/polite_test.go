@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rateLimitedScraper answers every request with a 429
+type rateLimitedScraper struct {
+	retryAfter time.Duration
+	calls      int
+}
+
+func (s *rateLimitedScraper) Scrape(ctx context.Context, rawURL string) (*Page, error) {
+	s.calls++
+	return nil, &Error{Kind: ErrBlocked, URL: rawURL, StatusCode: http.StatusTooManyRequests, RetryAfter: s.retryAfter}
+}
+
+func TestPoliteCapsRetryAfter(t *testing.T) {
+	next := &rateLimitedScraper{retryAfter: time.Hour}
+	settings := PolitenessSettings{MaxPerHost: 1, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, MaxRetries: 3}
+	polite := NewPolite(next, NewGate(settings))
+
+	started := time.Now()
+	_, err := polite.Scrape(context.Background(), "https://acme.com/about")
+	if KindOf(err) != ErrBlocked {
+		t.Fatalf("Scrape() = %v, want %s", err, ErrBlocked)
+	}
+	if next.calls != 1 {
+		t.Errorf("calls = %d, want 1, a Retry-After above MaxBackoff is not waited for", next.calls)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Scrape() took %v", elapsed)
+	}
+}
+
+func TestBackOffCapsDelay(t *testing.T) {
+	settings := PolitenessSettings{Backoff: time.Second, MaxBackoff: 4 * time.Second}
+	host := &hostState{}
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if got := host.backOff(settings, 0); got != want {
+			t.Errorf("backOff() = %v, want %v", got, want)
+		}
+	}
+	if got := host.backOff(settings, time.Hour); got != 4*time.Second {
+		t.Errorf("backOff(1h) = %v, want %v", got, 4*time.Second)
+	}
+}
+
+func TestPoliteRetriesShortRetryAfter(t *testing.T) {
+	next := &rateLimitedScraper{retryAfter: time.Millisecond}
+	settings := PolitenessSettings{MaxPerHost: 1, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, MaxRetries: 2}
+	if _, err := NewPolite(next, NewGate(settings)).Scrape(context.Background(), "https://acme.com/about"); err == nil {
+		t.Fatal("Scrape() succeeded, want the 429")
+	}
+	if next.calls != 3 {
+		t.Errorf("calls = %d, want 3", next.calls)
+	}
+}
+
+func TestSlowRobotsDoesNotBlockTheHost(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	gate := NewGate(PolitenessSettings{MaxPerHost: 2, Backoff: time.Millisecond, RespectRobots: true, RobotsTTL: time.Hour})
+	fetching := make(chan error, 1)
+	go func() {
+		_, err := gate.Acquire(context.Background(), server.URL+"/private")
+		fetching <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		gate.BackOff(server.URL+"/about", 0)
+		gate.Succeeded(server.URL + "/about")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BackOff() and Succeeded() waited for robots.txt")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := gate.Acquire(ctx, server.URL+"/about"); KindOf(err) != ErrTimeout {
+		t.Errorf("Acquire() while robots.txt is fetched = %v, want %s", err, ErrTimeout)
+	}
+
+	release <- struct{}{}
+	if err := <-fetching; KindOf(err) != ErrDisallowed {
+		t.Errorf("Acquire(/private) = %v, want %s", err, ErrDisallowed)
+	}
+}
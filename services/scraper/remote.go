@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// remoteScraper delegates scraping to the external scraper service
+type remoteScraper struct {
+	uri        string
+	httpClient *http.Client
+}
+
+// NewRemote returns a scraper that posts every URL to the scraper service at uri,
+// the service must answer within timeout
+func NewRemote(uri string, timeout time.Duration) Scraper {
+	return &remoteScraper{uri: uri, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Scrape function to scarp data using URL
+func (s *remoteScraper) Scrape(ctx context.Context, url string) (*Page, error) {
+	requestBody := map[string]interface{}{"url": url}
+
+	// Marshal request body to JSON
+	reqBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	// Send POST request to scrapper URI
+	req, err := http.NewRequestWithContext(ctx, "POST", s.uri+"/scrape", bytes.NewBuffer(reqBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error occurred while making the scrape request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the HTTP request
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, requestError(url, fmt.Errorf("error occurred while generating the response: %w", err))
+	}
+	defer resp.Body.Close()
+	// The scraper service relays the status of the scraped page
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(url, resp)
+	}
+
+	// Read and unmarshal the response
+	var scrapeResponse struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"` // Scraped data
+			} `json:"message"`
+		} `json:"choices"`
+	}
+
+	scrapeResBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, requestError(url, fmt.Errorf("error occurred while reading the response body: %w", err))
+	}
+
+	err = json.Unmarshal(scrapeResBody, &scrapeResponse)
+	if err != nil {
+		return nil, &Error{Kind: ErrUpstream, URL: url, Err: fmt.Errorf("error occurred while unmarshaling the response body: %w", err)}
+	}
+
+	// Check if any scraped content is available
+	if len(scrapeResponse.Choices) > 0 && strings.TrimSpace(scrapeResponse.Choices[0].Message.Content) != "" {
+		content := scrapeResponse.Choices[0].Message.Content
+		return &Page{URL: url, Text: content, Content: content}, nil
+	}
+
+	return nil, emptyError(url)
+}
@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsAgent is the product token matched against the User-agent lines of robots.txt
+const robotsAgent = "aiagent"
+
+// robotsRule allows or disallows the paths matching its pattern
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the rules of robots.txt that apply to this scraper
+type robotsRules struct {
+	rules []robotsRule
+	// crawlDelay is the Crawl-delay requested for this scraper, zero when not set
+	crawlDelay time.Duration
+}
+
+// allowAll is used when a site has no robots.txt
+var allowAll = &robotsRules{}
+
+// disallowAll is used when robots.txt could not be fetched because of a server error
+var disallowAll = &robotsRules{rules: []robotsRule{{pattern: "/"}}}
+
+// parseRobots reads the group of robots.txt that names this scraper, or the
+// "*" group when no group names it
+func parseRobots(document io.Reader) *robotsRules {
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(document, 512<<10))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, robotsRule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var fallback *robotsRules
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == "*" {
+				if fallback == nil {
+					fallback = &group.rules
+				}
+			} else if strings.Contains(robotsAgent, agent) || strings.Contains(agent, robotsAgent) {
+				return &group.rules
+			}
+		}
+	}
+	if fallback == nil {
+		return allowAll
+	}
+	return fallback
+}
+
+// allowed applies the longest matching rule to the path, Allow wins a tie
+func (r *robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern matches a path prefix where "*" matches any characters
+// and a trailing "$" anchors the end of the path
+func matchRobotsPattern(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return !anchored || rest == "" || (len(parts) > 1 && parts[len(parts)-1] == "")
+}
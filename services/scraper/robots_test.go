@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		path     string
+		allowed  bool
+	}{
+		{"no rules", "", "/about", true},
+		{"star group", "User-agent: *\nDisallow: /private", "/private/page", false},
+		{"star group other path", "User-agent: *\nDisallow: /private", "/about", true},
+		{"own group wins over star", "User-agent: *\nDisallow: /\n\nUser-agent: aiagent\nDisallow: /admin", "/about", true},
+		{"own group rules", "User-agent: *\nDisallow: /\n\nUser-agent: AIAgent\nDisallow: /admin", "/admin", false},
+		{"other agent ignored", "User-agent: googlebot\nDisallow: /", "/about", true},
+		{"shared group", "User-agent: googlebot\nUser-agent: *\nDisallow: /", "/about", false},
+		{"empty disallow", "User-agent: *\nDisallow:", "/about", true},
+		{"comments", "User-agent: * # everyone\nDisallow: /tmp # scratch", "/tmp/x", false},
+		{"robots.txt always allowed", "User-agent: *\nDisallow: /", "/robots.txt", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(test.document))
+			if got := rules.allowed(test.path); got != test.allowed {
+				t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.allowed)
+			}
+		})
+	}
+}
+
+func TestRobotsLongestMatch(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`User-agent: *
+Disallow: /
+Allow: /blog
+Disallow: /blog/drafts
+Allow: /*.html$
+Disallow: /*?session=
+Crawl-delay: 2.5
+`))
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", false},
+		{"/about", false},
+		{"/blog", true},
+		{"/blog/post", true},
+		{"/blog/drafts/post", false},
+		{"/page.html", true},
+		{"/page.html?x=1", false},
+		{"/blog/post?session=1", false},
+		{"/blog/post?page=1", true},
+	}
+	for _, test := range tests {
+		if got := rules.allowed(test.path); got != test.allowed {
+			t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.allowed)
+		}
+	}
+	if rules.crawlDelay != 2500*time.Millisecond {
+		t.Errorf("crawlDelay = %v, want 2.5s", rules.crawlDelay)
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/", "/", true},
+		{"/", "/anything", true},
+		{"/a", "/b", false},
+		{"/*.pdf", "/docs/file.pdf", true},
+		{"/*.pdf$", "/docs/file.pdf", true},
+		{"/*.pdf$", "/docs/file.pdf?dl=1", false},
+		{"/$", "/", true},
+		{"/$", "/about", false},
+		{"/a*b*c", "/a-x-b-y-c-z", true},
+		{"/a*b*c", "/a-x-c-y-b", false},
+		{"/end*$", "/end/anything", true},
+	}
+	for _, test := range tests {
+		if got := matchRobotsPattern(test.pattern, test.path); got != test.match {
+			t.Errorf("matchRobotsPattern(%q, %q) = %v, want %v", test.pattern, test.path, got, test.match)
+		}
+	}
+}
+
+// staticScraper returns a page for every URL
+type staticScraper struct{}
+
+func (staticScraper) Scrape(ctx context.Context, rawURL string) (*Page, error) {
+	return &Page{URL: rawURL, Content: "ok"}, nil
+}
+
+func TestPoliteRespectsRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /$\nDisallow: /*?private=")
+			return
+		}
+		fmt.Fprint(w, "page")
+	}))
+	defer server.Close()
+
+	polite := NewPolite(staticScraper{}, NewGate(PolitenessSettings{MaxPerHost: 1, RespectRobots: true, RobotsTTL: time.Minute}))
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{server.URL, false},
+		{server.URL + "/", false},
+		{server.URL + "/about", true},
+		{server.URL + "/about?private=1", false},
+	}
+	for _, test := range tests {
+		_, err := polite.Scrape(context.Background(), test.url)
+		if test.allowed && err != nil {
+			t.Errorf("Scrape(%q) = %v, want allowed", test.url, err)
+		}
+		if !test.allowed && KindOf(err) != ErrDisallowed {
+			t.Errorf("Scrape(%q) = %v, want %s", test.url, err, ErrDisallowed)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported scraper implementations
+const (
+	KindRemote = "remote"
+	KindNative = "native"
+)
+
+// Scraper fetches a web page and returns its readable content
+type Scraper interface {
+	Scrape(ctx context.Context, url string) (*Page, error)
+}
+
+// Page is the readable content of a scraped URL
+type Page struct {
+	URL         string   `json:"url" bson:"url"`
+	Title       string   `json:"title,omitempty" bson:"title,omitempty"`
+	Description string   `json:"description,omitempty" bson:"description,omitempty"`
+	Headings    []string `json:"headings,omitempty" bson:"headings,omitempty"`
+	Text        string   `json:"text,omitempty" bson:"text,omitempty"`
+	// Links are the absolute URLs the page links to, only set by the native scraper
+	Links []string `json:"links,omitempty" bson:"links,omitempty"`
+	// Content is the combined text used in prompts
+	Content string `json:"content" bson:"content"`
+	// RawContent is Content before cleaning, kept for debugging
+	RawContent string `json:"raw_content,omitempty" bson:"raw_content,omitempty"`
+}
+
+// New builds the scraper selected by SCRAPER. It defaults to the remote
+// scraper service when SCRAPPERURI is set and to the native fetcher otherwise.
+// Every scrape times out after SCRAPER_TIMEOUT_SECONDS, 30 by default.
+func New() Scraper {
+	timeoutSeconds, err := strconv.Atoi(os.Getenv("SCRAPER_TIMEOUT_SECONDS"))
+	if err != nil || timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+
+	kind := strings.ToLower(os.Getenv("SCRAPER"))
+	if kind == "" {
+		kind = KindNative
+		if os.Getenv("SCRAPPERURI") != "" {
+			kind = KindRemote
+		}
+	}
+	if kind == KindRemote {
+		return NewRemote(os.Getenv("SCRAPPERURI"), timeout)
+	}
+	return NewNative(timeout)
+}
@@ -0,0 +1,54 @@
+package spreadsheet
+
+import (
+	"regexp"
+	"strings"
+)
+
+var headerSeparators = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// NormalizeHeader lower cases the header and replaces punctuation and
+// repeated spaces with a single space, so "LinkedIn_Profile-URL" matches
+// "linkedin profile url"
+func NormalizeHeader(header string) string {
+	return strings.TrimSpace(headerSeparators.ReplaceAllString(strings.ToLower(header), " "))
+}
+
+// Columns maps field names to the index of their column
+type Columns map[string]int
+
+// MatchColumns finds the column of every field. aliases lists the accepted
+// headers per field in order of preference, and fields lists the fields in
+// the order they pick their column, a column is used by one field at most.
+func MatchColumns(headers []string, fields []string, aliases map[string][]string) Columns {
+	indexes := make(map[string]int, len(headers))
+	for index, header := range headers {
+		normalized := NormalizeHeader(header)
+		if _, exists := indexes[normalized]; !exists && normalized != "" {
+			indexes[normalized] = index
+		}
+	}
+
+	columns := make(Columns)
+	used := make(map[int]bool)
+	for _, field := range fields {
+		for _, alias := range aliases[field] {
+			if index, exists := indexes[NormalizeHeader(alias)]; exists && !used[index] {
+				columns[field] = index
+				used[index] = true
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// Value returns the trimmed cell of the field, empty when the field has no
+// column or the row is shorter than the header
+func (c Columns) Value(row []string, field string) string {
+	index, exists := c[field]
+	if !exists || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
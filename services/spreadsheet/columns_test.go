@@ -0,0 +1,116 @@
+package spreadsheet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"Name", "name"},
+		{"  E-Mail  ", "e mail"},
+		{"LinkedIn_Profile-URL", "linkedin profile url"},
+		{"Mobile   No.", "mobile no"},
+		{"Título", "título"},
+		{"***", ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeHeader(test.header); got != test.want {
+			t.Errorf("NormalizeHeader(%q) = %q, want %q", test.header, got, test.want)
+		}
+	}
+}
+
+func TestMatchColumns(t *testing.T) {
+	fields := []string{"name", "company url", "company"}
+	aliases := map[string][]string{
+		"name":        {"name", "full name"},
+		"company url": {"company url", "website", "company"},
+		"company":     {"company", "company name"},
+	}
+	tests := []struct {
+		name    string
+		headers []string
+		aliases map[string][]string
+		want    Columns
+	}{
+		{
+			name:    "exact headers",
+			headers: []string{"name", "company", "company url"},
+			want:    Columns{"name": 0, "company url": 2, "company": 1},
+		},
+		{
+			name:    "case and separators",
+			headers: []string{" FULL_NAME ", "Company-Name", "WebSite"},
+			want:    Columns{"name": 0, "company url": 2, "company": 1},
+		},
+		{
+			name:    "first alias wins",
+			headers: []string{"full name", "name"},
+			want:    Columns{"name": 1},
+		},
+		{
+			name:    "earlier field claims a shared alias",
+			headers: []string{"name", "company"},
+			want:    Columns{"name": 0, "company url": 1},
+		},
+		{
+			name:    "unmapped headers are ignored",
+			headers: []string{"notes", "name", ""},
+			want:    Columns{"name": 1},
+		},
+		{
+			name:    "duplicate headers use the first column",
+			headers: []string{"Name", "name", "company name"},
+			want:    Columns{"name": 0, "company": 2},
+		},
+		{
+			name:    "profile alias in front of the defaults",
+			headers: []string{"name", "Contact", "company"},
+			aliases: map[string][]string{
+				"name":        {"contact", "name", "full name"},
+				"company url": aliases["company url"],
+				"company":     aliases["company"],
+			},
+			want: Columns{"name": 1, "company url": 2},
+		},
+		{
+			name:    "no headers",
+			headers: nil,
+			want:    Columns{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testAliases := test.aliases
+			if testAliases == nil {
+				testAliases = aliases
+			}
+			if got := MatchColumns(test.headers, fields, testAliases); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("MatchColumns() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestColumnsValue(t *testing.T) {
+	columns := Columns{"name": 0, "email": 2}
+	tests := []struct {
+		row   []string
+		field string
+		want  string
+	}{
+		{[]string{" Ann ", "x", "ann@acme.com"}, "name", "Ann"},
+		{[]string{"Ann", "x", "ann@acme.com"}, "email", "ann@acme.com"},
+		{[]string{"Ann"}, "email", ""},
+		{[]string{"Ann", "x", "ann@acme.com"}, "company", ""},
+	}
+	for _, test := range tests {
+		if got := columns.Value(test.row, test.field); got != test.want {
+			t.Errorf("Value(%q, %q) = %q, want %q", test.row, test.field, got, test.want)
+		}
+	}
+}
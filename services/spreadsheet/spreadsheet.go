@@ -0,0 +1,187 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// Supported file formats
+const (
+	FormatXLSX = "xlsx"
+	FormatCSV  = "csv"
+	FormatTSV  = "tsv"
+)
+
+// defaultSheet is the sheet read from workbooks
+const defaultSheet = "Sheet1"
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+	// oleMagic starts legacy binary .xls files
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	utf8BOM  = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// ErrLegacyXLS is returned for binary .xls files, which must be saved as .xlsx or .csv first
+var ErrLegacyXLS = errors.New("legacy .xls files are not supported, save the file as .xlsx or .csv")
+
+// DetectFormat recognizes workbooks by their content, so .xls files saved as
+// xlsx are accepted, and text files by their extension or else by the
+// separator found in the first line
+func DetectFormat(data []byte, filename string) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return FormatXLSX, nil
+	case bytes.HasPrefix(data, oleMagic):
+		return "", ErrLegacyXLS
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".tsv", ".tab":
+		return FormatTSV, nil
+	case ".xlsx", ".xls":
+		return "", fmt.Errorf("%s is not a valid workbook", filename)
+	}
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		return FormatTSV, nil
+	}
+	return FormatCSV, nil
+}
+
+// Workbook is an uploaded file, CSV and TSV files have a single sheet
+type Workbook struct {
+	Format string
+	// Sheets are the sheet names in workbook order
+	Sheets []string
+
+	excel *excelize.File
+	rows  [][]string
+}
+
+// Open detects the format of the file and reads it
+func Open(data []byte, filename string) (*Workbook, error) {
+	format, err := DetectFormat(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	if format != FormatXLSX {
+		rows, err := readDelimited(data, format)
+		if err != nil {
+			return nil, err
+		}
+		return &Workbook{Format: format, Sheets: []string{strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))}, rows: rows}, nil
+	}
+
+	excel, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	sheetMap := excel.GetSheetMap()
+	indexes := make([]int, 0, len(sheetMap))
+	for index := range sheetMap {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	workbook := &Workbook{Format: FormatXLSX, excel: excel}
+	for _, index := range indexes {
+		workbook.Sheets = append(workbook.Sheets, sheetMap[index])
+	}
+	return workbook, nil
+}
+
+// SheetName resolves a sheet given by name, case insensitive, or by its
+// 1-based position. An empty sheet selects Sheet1 when the workbook has one
+// and the first sheet otherwise.
+func (w *Workbook) SheetName(sheet string) (string, error) {
+	sheet = strings.TrimSpace(sheet)
+	if len(w.Sheets) == 0 {
+		return "", errors.New("the workbook has no sheets")
+	}
+	if sheet == "" {
+		for _, name := range w.Sheets {
+			if name == defaultSheet {
+				return name, nil
+			}
+		}
+		return w.Sheets[0], nil
+	}
+	for _, name := range w.Sheets {
+		if strings.EqualFold(name, sheet) {
+			return name, nil
+		}
+	}
+	if index, err := strconv.Atoi(sheet); err == nil && index >= 1 && index <= len(w.Sheets) {
+		return w.Sheets[index-1], nil
+	}
+	return "", fmt.Errorf("sheet %q not found, the file has the sheets %s", sheet, strings.Join(w.Sheets, ", "))
+}
+
+// Rows returns the rows of the sheet, the first row being the header
+func (w *Workbook) Rows(sheet string) ([][]string, error) {
+	name, err := w.SheetName(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if w.excel == nil {
+		return w.rows, nil
+	}
+	return w.excel.GetRows(name), nil
+}
+
+// Read returns the rows of a sheet of the file, see Workbook.SheetName
+func Read(data []byte, filename string, sheet string) ([][]string, error) {
+	workbook, err := Open(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	return workbook.Rows(sheet)
+}
+
+// readDelimited reads a CSV or TSV file. CSV files exported with a semicolon
+// separator, as done by spreadsheet software in many locales, are accepted.
+func readDelimited(data []byte, format string) ([][]string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if format == FormatTSV {
+		reader.Comma = '\t'
+	} else {
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the %s file: %w", format, err)
+	}
+	return rows, nil
+}
+
+// Write creates a workbook with a single sheet holding the rows, the first
+// row being the header
+func Write(sheet string, rows [][]string) ([]byte, error) {
+	excel := excelize.NewFile()
+	excel.SetSheetName(defaultSheet, sheet)
+	for i := range rows {
+		excel.SetSheetRow(sheet, "A"+strconv.Itoa(i+1), &rows[i])
+	}
+	buffer, err := excel.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package spreadsheet
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{"workbook by content", "PK\x03\x04rest", "prospects.xlsx", FormatXLSX, false},
+		{"workbook saved as xls", "PK\x03\x04rest", "prospects.xls", FormatXLSX, false},
+		{"workbook without extension", "PK\x03\x04rest", "prospects", FormatXLSX, false},
+		{"legacy xls", "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1rest", "prospects.xls", "", true},
+		{"broken workbook", "name,email", "prospects.xlsx", "", true},
+		{"csv extension", "name\temail", "prospects.csv", FormatCSV, false},
+		{"tsv extension", "name,email", "prospects.tsv", FormatTSV, false},
+		{"tab extension", "name,email", "PROSPECTS.TAB", FormatTSV, false},
+		{"tabs in the first line", "name\temail\tcompany\nann\tann@acme.com,inc\tacme", "prospects.txt", FormatTSV, false},
+		{"commas in the first line", "name,email\nann\tx,ann@acme.com", "prospects", FormatCSV, false},
+		{"semicolons are csv", "name;email", "prospects", FormatCSV, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DetectFormat([]byte(test.data), test.filename)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DetectFormat() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormatLegacyXLS(t *testing.T) {
+	_, err := DetectFormat([]byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1rest"), "prospects.xls")
+	if !errors.Is(err, ErrLegacyXLS) {
+		t.Errorf("DetectFormat() error = %v, want %v", err, ErrLegacyXLS)
+	}
+}
+
+func TestReadDelimited(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		filename string
+		want     [][]string
+	}{
+		{
+			name:     "comma",
+			data:     "name,email\nAnn,ann@acme.com\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "semicolon",
+			data:     "name;email;company\nAnn;ann@acme.com;Acme, Inc.\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email", "company"}, {"Ann", "ann@acme.com", "Acme, Inc."}},
+		},
+		{
+			name:     "tab",
+			data:     "name\temail\nAnn\tann@acme.com\n",
+			filename: "prospects.tsv",
+			want:     [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "byte order mark",
+			data:     "\xEF\xBB\xBFname,email\nAnn,ann@acme.com\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "crlf line endings",
+			data:     "name,email\r\nAnn,ann@acme.com\r\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "empty lines are skipped",
+			data:     "name,email\n\nAnn,ann@acme.com\n\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "rows of empty cells are kept",
+			data:     "name,email\n,\nAnn,ann@acme.com\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email"}, {"", ""}, {"Ann", "ann@acme.com"}},
+		},
+		{
+			name:     "ragged rows",
+			data:     "name,email,company\nAnn\nBob,bob@acme.com,Acme,extra\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "email", "company"}, {"Ann"}, {"Bob", "bob@acme.com", "Acme", "extra"}},
+		},
+		{
+			name:     "quotes",
+			data:     "name,note\nAnn,\"says \"\"hi\"\", twice\"\nBob,5\" tall\n",
+			filename: "prospects.csv",
+			want:     [][]string{{"name", "note"}, {"Ann", "says \"hi\", twice"}, {"Bob", "5\" tall"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Read([]byte(test.data), test.filename, "")
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Read() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOpenDelimitedSheet(t *testing.T) {
+	workbook, err := Open([]byte("name,email\n"), "uploads/prospects.csv")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if workbook.Format != FormatCSV || !reflect.DeepEqual(workbook.Sheets, []string{"prospects"}) {
+		t.Errorf("Open() = %s %v, want csv with the sheet prospects", workbook.Format, workbook.Sheets)
+	}
+	if _, err := workbook.Rows("other"); err == nil {
+		t.Error("Rows(other) succeeded, want an unknown sheet error")
+	}
+}
+
+func TestWriteAndOpenWorkbook(t *testing.T) {
+	rows := [][]string{{"name", "email"}, {"Ann", "ann@acme.com"}}
+	data, err := Write("Prospects", rows)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	workbook, err := Open(data, "prospects.xlsx")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if workbook.Format != FormatXLSX {
+		t.Errorf("Format = %q, want %q", workbook.Format, FormatXLSX)
+	}
+	for _, sheet := range []string{"", "prospects", "1"} {
+		got, err := workbook.Rows(sheet)
+		if err != nil {
+			t.Fatalf("Rows(%q) error = %v", sheet, err)
+		}
+		if !reflect.DeepEqual(got, rows) {
+			t.Errorf("Rows(%q) = %q, want %q", sheet, got, rows)
+		}
+	}
+}
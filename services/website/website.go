@@ -0,0 +1,277 @@
+package website
+
+import (
+	"aiagent/services/scraper"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Sources of a resolved company website
+const (
+	// SourceColumn is used when the upload had a company url value
+	SourceColumn = "column"
+	// SourceEmailDomain is used when the website was derived from the email domain
+	SourceEmailDomain = "email_domain"
+	// SourceCompanyName is used when the website was guessed from the company name
+	SourceCompanyName = "company_name"
+)
+
+// userAgent identifies the resolver to the sites it probes
+const userAgent = "Mozilla/5.0 (compatible; aiagent/1.0; +https://initializ.ai)"
+
+// freeMailProviders host mailboxes of people from any company, matched on the
+// registrable domain without its public suffix, e.g. "yahoo" for yahoo.co.uk
+var freeMailProviders = map[string]bool{
+	"gmail": true, "googlemail": true, "yahoo": true, "ymail": true, "rocketmail": true,
+	"outlook": true, "hotmail": true, "live": true, "msn": true, "passport": true,
+	"icloud": true, "me": true, "mac": true, "aol": true, "aim": true,
+	"proton": true, "protonmail": true, "pm": true, "tutanota": true, "tuta": true,
+	"zoho": true, "zohomail": true, "yandex": true, "mail": true, "inbox": true,
+	"gmx": true, "web": true, "t-online": true, "rediffmail": true, "qq": true,
+	"163": true, "126": true, "sina": true, "naver": true, "daum": true,
+	"fastmail": true, "hey": true, "hushmail": true, "mailinator": true, "comcast": true,
+}
+
+// legalSuffixes are dropped from company names before guessing a domain
+var legalSuffixes = regexp.MustCompile(`(?i)\b(inc|incorporated|llc|llp|ltd|limited|plc|corp|corporation|co|company|gmbh|ag|sa|sas|srl|bv|nv|pvt|private|pte|pty|oy|ab|as)\b\.?`)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Resolution is the website found for a company
+type Resolution struct {
+	URL string
+	// Source tells how the website was derived
+	Source string
+}
+
+// How long probe results are remembered, and for how many domains
+const (
+	foundTTL   = 24 * time.Hour
+	missingTTL = time.Hour
+	maxProbed  = 10000
+)
+
+// probeResult is the working base URL of a probed domain, empty when the
+// domain does not exist or no variant of it answered successfully
+type probeResult struct {
+	base    string
+	expires time.Time
+}
+
+// Resolver finds the website of a company from the upload values
+type Resolver struct {
+	httpClient *http.Client
+	// gate applies the per host limits and robots.txt checks of the scraper, nil disables them
+	gate *scraper.Gate
+
+	mu     sync.Mutex
+	probed map[string]probeResult
+}
+
+// NewResolver returns a resolver that waits at most timeout for every probe
+// and sends the probes through the gate
+func NewResolver(timeout time.Duration, gate *scraper.Gate) *Resolver {
+	return &Resolver{
+		httpClient: &http.Client{Timeout: timeout},
+		gate:       gate,
+		probed:     make(map[string]probeResult),
+	}
+}
+
+// Resolve returns the website of the company. The company url value is used
+// when present, otherwise the email domain unless it belongs to a free mail
+// provider, and finally a .com domain guessed from the company name. Only
+// websites that answer are returned, except a company url value that is kept
+// as written when no variant of it answers.
+func (r *Resolver) Resolve(ctx context.Context, companyURL string, email string, companyName string) (*Resolution, error) {
+	if companyURL = strings.TrimSpace(companyURL); companyURL != "" {
+		if host := hostOf(companyURL); host != "" {
+			if base := r.probe(ctx, host); base != "" {
+				return &Resolution{URL: base, Source: SourceColumn}, nil
+			}
+		}
+		if !strings.Contains(companyURL, "://") {
+			companyURL = "https://" + companyURL
+		}
+		return &Resolution{URL: companyURL, Source: SourceColumn}, nil
+	}
+
+	if domain, ok := companyDomain(email); ok {
+		for _, host := range candidateHosts(domain) {
+			if base := r.probe(ctx, host); base != "" {
+				return &Resolution{URL: base, Source: SourceEmailDomain}, nil
+			}
+		}
+	}
+
+	if guess := guessDomain(companyName); guess != "" {
+		if base := r.probe(ctx, guess); base != "" {
+			return &Resolution{URL: base, Source: SourceCompanyName}, nil
+		}
+	}
+	return nil, fmt.Errorf("no website found from the company url, email domain or company name")
+}
+
+// companyDomain returns the domain of a work email address
+func companyDomain(email string) (string, bool) {
+	_, domain, found := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	domain = strings.Trim(domain, ". ")
+	if !found || !strings.Contains(domain, ".") {
+		return "", false
+	}
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return "", false
+	}
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	if freeMailProviders[strings.TrimSuffix(registrable, "."+suffix)] {
+		return "", false
+	}
+	return domain, true
+}
+
+// candidateHosts returns the registrable domain first and then the mail
+// subdomain, e.g. acme.co.uk and eu.acme.co.uk for someone@eu.acme.co.uk
+func candidateHosts(domain string) []string {
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil || registrable == domain {
+		return []string{domain}
+	}
+	return []string{registrable, domain}
+}
+
+// guessDomain turns a company name into a .com domain, e.g. "Acme Widgets, Inc." into acmewidgets.com
+func guessDomain(companyName string) string {
+	name := legalSuffixes.ReplaceAllString(companyName, "")
+	name = strings.ReplaceAll(strings.ToLower(name), "&", "and")
+	name = nonAlphanumeric.ReplaceAllString(name, "")
+	if name == "" {
+		return ""
+	}
+	return name + ".com"
+}
+
+// hostOf returns the host of a URL written with or without scheme
+func hostOf(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// probe tries https and http, with and without www, and returns the base URL
+// of the first variant that answers, after redirects. Domains that answered
+// or do not exist are remembered, failures that may be temporary such as
+// timeouts are probed again next time.
+func (r *Resolver) probe(ctx context.Context, host string) string {
+	bare := strings.TrimPrefix(host, "www.")
+	r.mu.Lock()
+	result, done := r.probed[bare]
+	r.mu.Unlock()
+	if done && time.Now().Before(result.expires) {
+		return result.base
+	}
+
+	base, definitive := "", true
+	for _, scheme := range []string{"https", "http"} {
+		for _, candidate := range []string{"www." + bare, bare} {
+			found, answered := r.fetch(ctx, scheme+"://"+candidate+"/")
+			if found != "" {
+				base = found
+				break
+			}
+			definitive = definitive && answered
+		}
+		if base != "" {
+			break
+		}
+	}
+	if base == "" && !definitive {
+		return ""
+	}
+
+	ttl := foundTTL
+	if base == "" {
+		ttl = missingTTL
+	}
+	r.remember(bare, probeResult{base: base, expires: time.Now().Add(ttl)})
+	return base
+}
+
+// remember stores the result, expired entries are dropped when the map is
+// full and an arbitrary entry when none expired
+func (r *Resolver) remember(domain string, result probeResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.probed[domain]; !exists && len(r.probed) >= maxProbed {
+		now := time.Now()
+		for key, entry := range r.probed {
+			if now.After(entry.expires) {
+				delete(r.probed, key)
+			}
+		}
+		for key := range r.probed {
+			if len(r.probed) < maxProbed {
+				break
+			}
+			delete(r.probed, key)
+		}
+	}
+	r.probed[domain] = result
+}
+
+// fetch returns the base URL the site ended on, or an empty string when it
+// did not answer successfully. answered is false when the failure may be
+// temporary: a timeout, a network error or a server error.
+func (r *Resolver) fetch(ctx context.Context, target string) (base string, answered bool) {
+	if r.gate != nil {
+		release, err := r.gate.Acquire(ctx, target)
+		if scraper.KindOf(err) == scraper.ErrDisallowed {
+			// The site answered with a robots.txt that keeps us out of its home page
+			parsed, _ := url.Parse(target)
+			return parsed.Scheme + "://" + parsed.Host, true
+		}
+		if err != nil {
+			return "", false
+		}
+		defer release()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", true
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		var dnsErr *net.DNSError
+		return "", errors.As(err, &dnsErr) && dnsErr.IsNotFound
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode == http.StatusTooManyRequests && r.gate != nil {
+		r.gate.BackOff(target, 0)
+	}
+	// Sites behind bot protection answer 401, 403 or 429 but do exist
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return "", resp.StatusCode < 500
+	}
+	final := resp.Request.URL
+	return final.Scheme + "://" + final.Host, true
+}
@@ -0,0 +1,137 @@
+package website
+
+import (
+	"aiagent/services/scraper"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompanyDomain(t *testing.T) {
+	tests := []struct {
+		email  string
+		domain string
+		ok     bool
+	}{
+		{"ann@acme.com", "acme.com", true},
+		{" Ann@EU.Acme.co.uk ", "eu.acme.co.uk", true},
+		{"ann@gmail.com", "", false},
+		{"ann@yahoo.co.uk", "", false},
+		{"ann@outlook.de", "", false},
+		{"ann", "", false},
+		{"ann@localhost", "", false},
+	}
+	for _, test := range tests {
+		domain, ok := companyDomain(test.email)
+		if domain != test.domain || ok != test.ok {
+			t.Errorf("companyDomain(%q) = %q, %v, want %q, %v", test.email, domain, ok, test.domain, test.ok)
+		}
+	}
+}
+
+func TestCandidateHosts(t *testing.T) {
+	if got := candidateHosts("eu.acme.co.uk"); strings.Join(got, ",") != "acme.co.uk,eu.acme.co.uk" {
+		t.Errorf("candidateHosts() = %v", got)
+	}
+	if got := candidateHosts("acme.com"); strings.Join(got, ",") != "acme.com" {
+		t.Errorf("candidateHosts() = %v", got)
+	}
+}
+
+func TestGuessDomain(t *testing.T) {
+	tests := map[string]string{
+		"Acme Widgets, Inc.": "acmewidgets.com",
+		"Smith & Sons Ltd":   "smithandsons.com",
+		"Globex Corporation": "globex.com",
+		"Initech GmbH":       "initech.com",
+		"  ":                 "",
+		"Inc.":               "",
+	}
+	for name, want := range tests {
+		if got := guessDomain(name); got != want {
+			t.Errorf("guessDomain(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestProbeDoesNotRememberTimeouts(t *testing.T) {
+	var slow atomic.Bool
+	slow.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	resolver := NewResolver(50*time.Millisecond, nil)
+	if base := resolver.probe(context.Background(), host); base != "" {
+		t.Fatalf("probe() = %q while the site times out", base)
+	}
+	slow.Store(false)
+	if base := resolver.probe(context.Background(), host); base != server.URL {
+		t.Fatalf("probe() = %q after the site recovered, want %q", base, server.URL)
+	}
+
+	// Working sites are remembered
+	server.Close()
+	if base := resolver.probe(context.Background(), host); base != server.URL {
+		t.Errorf("probe() = %q, want the remembered %q", base, server.URL)
+	}
+}
+
+func TestRememberIsBounded(t *testing.T) {
+	resolver := NewResolver(time.Second, nil)
+	expired := probeResult{expires: time.Now().Add(-time.Minute)}
+	fresh := probeResult{base: "https://acme.com", expires: time.Now().Add(time.Hour)}
+	for i := 0; i < maxProbed; i++ {
+		result := fresh
+		if i%2 == 0 {
+			result = expired
+		}
+		resolver.probed[fmt.Sprintf("domain%d.com", i)] = result
+	}
+	resolver.remember("new.com", fresh)
+	if len(resolver.probed) != maxProbed/2+1 {
+		t.Errorf("%d domains remembered, want the expired ones dropped", len(resolver.probed))
+	}
+	resolver.probed = map[string]probeResult{}
+	for i := 0; i < maxProbed; i++ {
+		resolver.probed[fmt.Sprintf("domain%d.com", i)] = fresh
+	}
+	resolver.remember("new.com", fresh)
+	if len(resolver.probed) > maxProbed {
+		t.Errorf("%d domains remembered, want at most %d", len(resolver.probed), maxProbed)
+	}
+	if _, exists := resolver.probed["new.com"]; !exists {
+		t.Error("the new domain was not remembered")
+	}
+}
+
+func TestProbeGoesThroughTheGate(t *testing.T) {
+	var homeRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /")
+			return
+		}
+		homeRequests.Add(1)
+	}))
+	defer server.Close()
+
+	gate := scraper.NewGate(scraper.PolitenessSettings{MaxPerHost: 1, RespectRobots: true, RobotsTTL: time.Minute})
+	resolver := NewResolver(time.Second, gate)
+	host := strings.TrimPrefix(server.URL, "http://")
+	if base := resolver.probe(context.Background(), host); base != server.URL {
+		t.Errorf("probe() = %q, want %q, a robots.txt shows the site exists", base, server.URL)
+	}
+	if homeRequests.Load() != 0 {
+		t.Errorf("%d requests reached the disallowed home page", homeRequests.Load())
+	}
+}